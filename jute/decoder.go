@@ -0,0 +1,239 @@
+package jute
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// 解码器，从字节数组中按jute格式依次读出数据，数据不完整时返回ErrShortBuffer
+type Decoder struct {
+	buf []byte
+	off int
+}
+
+func NewDecoder(buf []byte) *Decoder {
+	return &Decoder{buf: buf}
+}
+
+// 已读取的字节数
+func (d *Decoder) Offset() int {
+	return d.off
+}
+
+// 剩余未读取的字节数
+func (d *Decoder) Len() int {
+	return len(d.buf) - d.off
+}
+
+// 取出接下来的n个字节
+func (d *Decoder) next(n int) ([]byte, error) {
+	if n < 0 || d.Len() < n {
+		return nil, ErrShortBuffer
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *Decoder) ReadByte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *Decoder) ReadBool() (bool, error) {
+	b, err := d.ReadByte()
+	return b != 0, err
+}
+
+func (d *Decoder) ReadInt() (int32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (d *Decoder) ReadLong() (int64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func (d *Decoder) ReadFloat() (float32, error) {
+	v, err := d.ReadInt()
+	return math.Float32frombits(uint32(v)), err
+}
+
+func (d *Decoder) ReadDouble() (float64, error) {
+	v, err := d.ReadLong()
+	return math.Float64frombits(uint64(v)), err
+}
+
+// 读取长度前缀，-1表示nil
+func (d *Decoder) readLen() (int, error) {
+	n, err := d.ReadInt()
+	if err != nil {
+		return 0, err
+	}
+	if n < -1 {
+		return 0, ErrBadLength
+	}
+	return int(n), nil
+}
+
+// 读取buffer，长度为-1时返回nil，返回的数组是拷贝
+func (d *Decoder) ReadBuffer() ([]byte, error) {
+	n, err := d.readLen()
+	if err != nil || n == -1 {
+		return nil, err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, b...), nil
+}
+
+func (d *Decoder) ReadString() (string, error) {
+	n, err := d.readLen()
+	if err != nil || n == -1 {
+		return "", err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// 读取vector的元素个数，-1表示nil
+func (d *Decoder) ReadVectorLen() (int, error) {
+	n, err := d.readLen()
+	if err != nil {
+		return 0, err
+	}
+	// 每个元素至少占一个字节，防止错误的长度导致分配过大的内存
+	if n > d.Len() {
+		return 0, ErrShortBuffer
+	}
+	return n, nil
+}
+
+// 读取一条记录到v，v必须是指针
+func (d *Decoder) Decode(v interface{}) error {
+	if r, ok := v.(Deserializer); ok {
+		return r.Deserialize(d)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidDecodeError{reflect.TypeOf(v)}
+	}
+	return d.decodeValue(rv.Elem())
+}
+
+func (d *Decoder) decodeValue(v reflect.Value) error {
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if r, ok := v.Addr().Interface().(Deserializer); ok {
+			return r.Deserialize(d)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.ReadBool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int8, reflect.Uint8:
+		b, err := d.ReadByte()
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Int8 {
+			v.SetInt(int64(int8(b)))
+		} else {
+			v.SetUint(uint64(b))
+		}
+	case reflect.Int32:
+		i, err := d.ReadInt()
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case reflect.Int64:
+		i, err := d.ReadLong()
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32:
+		f, err := d.ReadFloat()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(f))
+	case reflect.Float64:
+		f, err := d.ReadDouble()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		s, err := d.ReadString()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.ReadBuffer()
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		n, err := d.ReadVectorLen()
+		if err != nil {
+			return err
+		}
+		if n == -1 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decodeValue(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Struct:
+		for _, i := range recordFields(v.Type()) {
+			if err := d.decodeValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeValue(v.Elem())
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+// 从buf解码到v，返回读取的字节数
+func Unmarshal(buf []byte, v interface{}) (int, error) {
+	d := NewDecoder(buf)
+	err := d.Decode(v)
+	return d.Offset(), err
+}
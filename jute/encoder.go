@@ -0,0 +1,157 @@
+package jute
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// 编码器，按jute格式把数据追加到内部的字节数组
+type Encoder struct {
+	buf []byte
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{}
+}
+
+// 已编码的数据
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// 已编码数据的长度
+func (e *Encoder) Len() int {
+	return len(e.buf)
+}
+
+func (e *Encoder) WriteByte(v byte) error {
+	e.buf = append(e.buf, v)
+	return nil
+}
+
+func (e *Encoder) WriteBool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *Encoder) WriteInt(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *Encoder) WriteLong(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *Encoder) WriteFloat(v float32) {
+	e.WriteInt(int32(math.Float32bits(v)))
+}
+
+func (e *Encoder) WriteDouble(v float64) {
+	e.WriteLong(int64(math.Float64bits(v)))
+}
+
+// 写入buffer，nil编码为长度-1
+func (e *Encoder) WriteBuffer(v []byte) {
+	if v == nil {
+		e.WriteInt(-1)
+		return
+	}
+	e.WriteInt(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *Encoder) WriteString(v string) {
+	e.WriteInt(int32(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// 写入一条记录，v为实现了Serializer的类型，或由支持的类型组成的结构体（指针）
+func (e *Encoder) Encode(v interface{}) error {
+	if r, ok := v.(Serializer); ok {
+		return r.Serialize(e)
+	}
+	return e.encodeValue(reflect.ValueOf(v))
+}
+
+func (e *Encoder) encodeValue(v reflect.Value) error {
+	if v.CanInterface() {
+		if r, ok := v.Interface().(Serializer); ok {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				return ErrNilRecord
+			}
+			return r.Serialize(e)
+		}
+	}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if r, ok := v.Addr().Interface().(Serializer); ok {
+			return r.Serialize(e)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		e.WriteBool(v.Bool())
+	case reflect.Int8, reflect.Uint8:
+		if v.Kind() == reflect.Int8 {
+			e.WriteByte(byte(v.Int()))
+		} else {
+			e.WriteByte(byte(v.Uint()))
+		}
+	case reflect.Int32:
+		e.WriteInt(int32(v.Int()))
+	case reflect.Int64:
+		e.WriteLong(v.Int())
+	case reflect.Float32:
+		e.WriteFloat(float32(v.Float()))
+	case reflect.Float64:
+		e.WriteDouble(v.Float())
+	case reflect.String:
+		e.WriteString(v.String())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.WriteBuffer(v.Bytes())
+			return nil
+		}
+		// vector，nil编码为长度-1
+		if v.IsNil() {
+			e.WriteInt(-1)
+			return nil
+		}
+		e.WriteInt(int32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encodeValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, i := range recordFields(v.Type()) {
+			if err := e.encodeValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ErrNilRecord
+		}
+		return e.encodeValue(v.Elem())
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+// 把v编码成字节数组
+func Marshal(v interface{}) ([]byte, error) {
+	e := NewEncoder()
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
@@ -0,0 +1,83 @@
+// jute为ZooKeeper使用的序列化格式，所有整数均为大端：
+//
+//	int     4字节
+//	long    8字节
+//	bool    1字节
+//	buffer  int长度 + 数据，长度-1表示nil
+//	ustring 同buffer
+//	vector  int个数 + 各元素，个数-1表示nil
+//	record  依次排列的各字段
+//
+// 结构体按导出字段的定义顺序编码，Go类型与jute类型的对应关系为
+// int32/int、int64/long、bool/boolean、byte/byte、float32/float、float64/double、
+// []byte/buffer、string/ustring、切片/vector、结构体及其指针/record，
+// 带有`jute:"-"`标签的字段会被忽略。实现了Serializer、Deserializer接口的类型使用自身的编解码方法。
+package jute
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrShortBuffer = errors.New("jute: short buffer") // 数据不完整
+	ErrBadLength   = errors.New("jute: bad length")   // 长度前缀为负数
+	ErrNilRecord   = errors.New("jute: nil record")   // 要编码的记录为nil
+)
+
+// 类型不受支持
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "jute: unsupported type " + e.Type.String()
+}
+
+// 解码的目标不是非nil的指针
+type InvalidDecodeError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidDecodeError) Error() string {
+	if e.Type == nil {
+		return "jute: Decode(nil)"
+	}
+	return "jute: Decode(non-pointer " + e.Type.String() + ")"
+}
+
+// 自行实现编码的类型
+type Serializer interface {
+	Serialize(e *Encoder) error
+}
+
+// 自行实现解码的类型
+type Deserializer interface {
+	Deserialize(d *Decoder) error
+}
+
+// 自行实现编解码的记录
+type Record interface {
+	Serializer
+	Deserializer
+}
+
+var fieldCache sync.Map // reflect.Type -> []int
+
+// 结构体中参与编解码的字段下标
+func recordFields(t reflect.Type) []int {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]int)
+	}
+	fields := make([]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("jute") == "-" {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
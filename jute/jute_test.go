@@ -0,0 +1,200 @@
+package jute
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type inner struct {
+	Scheme string
+	Id     string
+}
+
+type record struct {
+	B       byte
+	Flag    bool
+	I       int32
+	L       int64
+	F       float32
+	D       float64
+	Buf     []byte
+	S       string
+	Ints    []int32
+	Strs    []string
+	Inner   inner
+	Inners  []inner
+	Ignored int32 `jute:"-"`
+	private int32
+}
+
+// 自行实现编解码的记录，编码为一个int
+type custom struct {
+	v int32
+}
+
+func (c *custom) Serialize(e *Encoder) error {
+	e.WriteInt(c.v * 2)
+	return nil
+}
+
+func (c *custom) Deserialize(d *Decoder) error {
+	v, err := d.ReadInt()
+	c.v = v / 2
+	return err
+}
+
+func roundTrip(t *testing.T, in, out interface{}) {
+	t.Helper()
+	buf, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal(%#v): %v", in, err)
+	}
+	n, err := Unmarshal(buf, out)
+	if err != nil {
+		t.Fatalf("Unmarshal(%x): %v", buf, err)
+	}
+	if n != len(buf) {
+		t.Fatalf("Unmarshal consumed %d of %d bytes", n, len(buf))
+	}
+	got := reflect.ValueOf(out).Elem().Interface()
+	want := reflect.ValueOf(in).Elem().Interface()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip: got %#v, want %#v", got, want)
+	}
+}
+
+func TestPrimitiveRoundTrip(t *testing.T) {
+	var (
+		b  = byte(0xfe)
+		t1 = true
+		i  = int32(math.MinInt32)
+		l  = int64(math.MaxInt64)
+		f  = float32(3.25)
+		d  = math.Inf(-1)
+		s  = "路径/节点"
+	)
+	roundTrip(t, &b, new(byte))
+	roundTrip(t, &t1, new(bool))
+	roundTrip(t, &i, new(int32))
+	roundTrip(t, &l, new(int64))
+	roundTrip(t, &f, new(float32))
+	roundTrip(t, &d, new(float64))
+	roundTrip(t, &s, new(string))
+}
+
+func TestBufferAndVectorRoundTrip(t *testing.T) {
+	for _, buf := range [][]byte{nil, {}, {0, 1, 2, 255}} {
+		in := buf
+		roundTrip(t, &in, new([]byte))
+	}
+	for _, v := range [][]string{nil, {}, {"a", "", "ccc"}} {
+		in := v
+		roundTrip(t, &in, new([]string))
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	in := &record{
+		B:      1,
+		Flag:   true,
+		I:      -2,
+		L:      1 << 40,
+		F:      -1.5,
+		D:      math.Pi,
+		Buf:    []byte("data"),
+		S:      "str",
+		Ints:   []int32{1, -1, 0},
+		Strs:   []string{"x", "y"},
+		Inner:  inner{"world", "anyone"},
+		Inners: []inner{{"digest", "u:p"}, {"ip", "127.0.0.1"}},
+	}
+	roundTrip(t, in, &record{})
+
+	// 忽略的字段不参与编解码
+	in.Ignored, in.private = 7, 8
+	out := &record{}
+	buf, _ := Marshal(in)
+	if _, err := Unmarshal(buf, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Ignored != 0 || out.private != 0 {
+		t.Fatalf("ignored fields decoded: %d %d", out.Ignored, out.private)
+	}
+}
+
+func TestSerializer(t *testing.T) {
+	in := &custom{21}
+	buf, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{0, 0, 0, 42}) {
+		t.Fatalf("custom encoding: %x", buf)
+	}
+	roundTrip(t, in, &custom{})
+}
+
+func TestWireFormat(t *testing.T) {
+	in := &struct {
+		I   int32
+		Buf []byte
+		S   []string
+	}{I: 1, Buf: nil, S: []string{"ab"}}
+	buf, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0, 0, 0, 1, // int
+		0xff, 0xff, 0xff, 0xff, // nil buffer
+		0, 0, 0, 1, 0, 0, 0, 2, 'a', 'b', // vector<ustring>
+	}
+	if !bytes.Equal(buf, want) {
+		t.Fatalf("got %x, want %x", buf, want)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	buf, err := Marshal(&record{Buf: []byte("data"), S: "str", Strs: []string{"x"}, Inners: []inner{{"a", "b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(buf); n++ {
+		if _, err := Unmarshal(buf[:n], &record{}); !errors.Is(err, ErrShortBuffer) {
+			t.Fatalf("Unmarshal of %d/%d bytes: got %v, want ErrShortBuffer", n, len(buf), err)
+		}
+	}
+}
+
+func TestBadLength(t *testing.T) {
+	// 长度为-2
+	bad := []byte{0xff, 0xff, 0xff, 0xfe, 'a'}
+	if _, err := Unmarshal(bad, new([]byte)); !errors.Is(err, ErrBadLength) {
+		t.Fatalf("buffer: got %v, want ErrBadLength", err)
+	}
+	if _, err := Unmarshal(bad, new(string)); !errors.Is(err, ErrBadLength) {
+		t.Fatalf("string: got %v, want ErrBadLength", err)
+	}
+	if _, err := Unmarshal(bad, new([]int32)); !errors.Is(err, ErrBadLength) {
+		t.Fatalf("vector: got %v, want ErrBadLength", err)
+	}
+	// 元素个数超过剩余数据，不应分配巨大的切片
+	huge := []byte{0x7f, 0xff, 0xff, 0xff}
+	if _, err := Unmarshal(huge, new([]int32)); !errors.Is(err, ErrShortBuffer) {
+		t.Fatalf("huge vector: got %v, want ErrShortBuffer", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var ue *UnsupportedTypeError
+	if _, err := Marshal(map[string]int{}); !errors.As(err, &ue) {
+		t.Fatalf("Marshal(map): got %v, want UnsupportedTypeError", err)
+	}
+	var ie *InvalidDecodeError
+	if _, err := Unmarshal([]byte{0, 0, 0, 1}, int32(0)); !errors.As(err, &ie) {
+		t.Fatalf("Unmarshal(non-pointer): got %v, want InvalidDecodeError", err)
+	}
+}
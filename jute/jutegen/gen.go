@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+type generator struct {
+	buf     bytes.Buffer
+	records map[string]*record // 记录名 -> 记录，所有模块生成到同一个包，记录名不能重复
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// 字段名首字母大写后导出
+func exportName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// 去掉模块前缀的记录名
+func recordName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func (g *generator) goType(t *juteType) string {
+	switch t.kind {
+	case "byte":
+		return "byte"
+	case "boolean":
+		return "bool"
+	case "int":
+		return "int32"
+	case "long":
+		return "int64"
+	case "float":
+		return "float32"
+	case "double":
+		return "float64"
+	case "ustring":
+		return "string"
+	case "buffer":
+		return "[]byte"
+	case "vector":
+		return "[]" + g.goType(t.elem)
+	}
+	return recordName(t.name)
+}
+
+var juteMethods = map[string]string{
+	"byte":    "Byte",
+	"boolean": "Bool",
+	"int":     "Int",
+	"long":    "Long",
+	"float":   "Float",
+	"double":  "Double",
+	"ustring": "String",
+	"buffer":  "Buffer",
+}
+
+func (g *generator) writeValue(t *juteType, expr string, depth int) {
+	switch t.kind {
+	case "record":
+		g.printf("if err := %s.Serialize(e); err != nil {\nreturn err\n}\n", expr)
+	case "vector":
+		i := fmt.Sprintf("i%d", depth)
+		g.printf("if %s == nil {\ne.WriteInt(-1)\n} else {\n", expr)
+		g.printf("e.WriteInt(int32(len(%s)))\n", expr)
+		g.printf("for %s := range %s {\n", i, expr)
+		g.writeValue(t.elem, expr+"["+i+"]", depth+1)
+		g.printf("}\n}\n")
+	default:
+		g.printf("e.Write%s(%s)\n", juteMethods[t.kind], expr)
+	}
+}
+
+func (g *generator) readValue(t *juteType, expr string, depth int) {
+	switch t.kind {
+	case "record":
+		g.printf("if err = %s.Deserialize(d); err != nil {\nreturn err\n}\n", expr)
+	case "vector":
+		n, i := fmt.Sprintf("n%d", depth), fmt.Sprintf("i%d", depth)
+		g.printf("{\nvar %s int\n", n)
+		g.printf("if %s, err = d.ReadVectorLen(); err != nil {\nreturn err\n}\n", n)
+		g.printf("if %s == -1 {\n%s = nil\n} else {\n", n, expr)
+		g.printf("%s = make(%s, %s)\n", expr, g.goType(t), n)
+		g.printf("for %s := range %s {\n", i, expr)
+		g.readValue(t.elem, expr+"["+i+"]", depth+1)
+		g.printf("}\n}\n}\n")
+	default:
+		g.printf("if %s, err = d.Read%s(); err != nil {\nreturn err\n}\n", expr, juteMethods[t.kind])
+	}
+}
+
+// 检查引用的记录都已定义
+func (g *generator) check(t *juteType) error {
+	switch t.kind {
+	case "vector":
+		return g.check(t.elem)
+	case "record":
+		if _, ok := g.records[recordName(t.name)]; !ok {
+			return fmt.Errorf("undefined record %s", t.name)
+		}
+	}
+	return nil
+}
+
+func (g *generator) genRecord(r *record) {
+	g.printf("// %s.%s\n", r.module, r.name)
+	g.printf("type %s struct {\n", r.name)
+	for _, f := range r.fields {
+		g.printf("%s %s\n", exportName(f.name), g.goType(f.typ))
+	}
+	g.printf("}\n\n")
+
+	g.printf("func (r *%s) Serialize(e *jute.Encoder) error {\n", r.name)
+	for _, f := range r.fields {
+		g.writeValue(f.typ, "r."+exportName(f.name), 1)
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("func (r *%s) Deserialize(d *jute.Decoder) error {\n", r.name)
+	if len(r.fields) > 0 {
+		g.printf("var err error\n")
+	}
+	for _, f := range r.fields {
+		g.readValue(f.typ, "r."+exportName(f.name), 1)
+	}
+	g.printf("return nil\n}\n\n")
+}
+
+// 生成所有模块的记录，返回格式化后的Go代码
+func generate(mods []*module, pkg string, source string) ([]byte, error) {
+	g := &generator{records: make(map[string]*record)}
+	for _, m := range mods {
+		for _, r := range m.records {
+			if old, ok := g.records[r.name]; ok {
+				return nil, fmt.Errorf("record %s defined in both %s and %s", r.name, old.module, r.module)
+			}
+			g.records[r.name] = r
+		}
+	}
+	for _, m := range mods {
+		for _, r := range m.records {
+			for _, f := range r.fields {
+				if err := g.check(f.typ); err != nil {
+					return nil, fmt.Errorf("%s.%s: %v", r.name, f.name, err)
+				}
+			}
+		}
+	}
+
+	g.printf("// Code generated by jutegen from %s. DO NOT EDIT.\n\n", source)
+	g.printf("package %s\n\n", pkg)
+	g.printf("import \"github.com/xianmau/gozk/jute\"\n\n")
+	for _, m := range mods {
+		for _, r := range m.records {
+			g.genRecord(r)
+		}
+	}
+	return format.Source(g.buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// 生成的代码必须与提交的proto/zookeeper.go一致，修改IDL或生成器后需要重新go generate
+func TestGeneratedUpToDate(t *testing.T) {
+	src, err := ioutil.ReadFile("../../proto/zookeeper.jute")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods, err := parse(string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := generate(mods, "proto", "zookeeper.jute")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("../../proto/zookeeper.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = bytes.Replace(want, []byte("\r\n"), []byte("\n"), -1)
+	if !bytes.Equal(code, want) {
+		gotLines, wantLines := strings.Split(string(code), "\n"), strings.Split(string(want), "\n")
+		for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+			if gotLines[i] != wantLines[i] {
+				t.Fatalf("proto/zookeeper.go is stale, run go generate in proto; first difference at line %d:\ngenerated: %s\nchecked in: %s", i+1, gotLines[i], wantLines[i])
+			}
+		}
+		t.Fatalf("proto/zookeeper.go is stale, run go generate in proto; generated %d lines, checked in %d", len(gotLines), len(wantLines))
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, src := range []string{
+		"module a { record R { map<ustring, ustring> m; } }",
+		"module a { record R { Missing m; } }",
+		"module a { record R { int x; } record R { int y; } }",
+		"module a { record R { int x } }",
+	} {
+		mods, err := parse(src)
+		if err == nil {
+			_, err = generate(mods, "p", "test.jute")
+		}
+		if err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// jutegen根据ZooKeeper的jute IDL（zookeeper.jute）生成Go的记录类型及其编解码方法，
+// 生成的类型实现jute.Record接口。用法：
+//
+//	//go:generate go run ../jute/jutegen -package proto -o zookeeper.go zookeeper.jute
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	pkg := flag.String("package", "proto", "生成代码的包名")
+	out := flag.String("o", "", "输出文件，默认为标准输出")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: jutegen [-package name] [-o file] zookeeper.jute")
+		os.Exit(2)
+	}
+	src, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	mods, err := parse(string(src))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	code, err := generate(mods, *pkg, filepath.Base(flag.Arg(0)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// jute类型，vector时elem为元素类型，record时name为记录名（可带模块前缀）
+type juteType struct {
+	kind string // byte、boolean、int、long、float、double、ustring、buffer、vector、record
+	elem *juteType
+	name string
+}
+
+type field struct {
+	name string
+	typ  *juteType
+}
+
+type record struct {
+	module string
+	name   string
+	fields []field
+}
+
+type module struct {
+	name    string
+	records []*record
+}
+
+type token struct {
+	text string
+	line int
+}
+
+// 词法分析，跳过注释，符号单独成词，其余按空白分隔
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.IndexByte("{}<>;,", c) >= 0:
+			toks = append(toks, token{string(c), line})
+			i++
+		case c == '_' || c == '.' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, token{src[i:j], line})
+			i = j
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos].text
+	}
+	return ""
+}
+
+func (p *parser) next() (string, error) {
+	if p.pos >= len(p.toks) {
+		return "", fmt.Errorf("unexpected end of file")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) expect(text string) error {
+	line := 0
+	if p.pos < len(p.toks) {
+		line = p.toks[p.pos].line
+	}
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t != text {
+		return fmt.Errorf("line %d: expected %q, got %q", line, text, t)
+	}
+	return nil
+}
+
+// 解析IDL：module 名字 { record 名字 { 类型 字段; ... } ... }
+func parse(src string) ([]*module, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	var mods []*module
+	for p.peek() != "" {
+		if err := p.expect("module"); err != nil {
+			return nil, err
+		}
+		name, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		m := &module{name: name}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		for p.peek() != "}" {
+			r, err := p.parseRecord(name)
+			if err != nil {
+				return nil, err
+			}
+			m.records = append(m.records, r)
+		}
+		p.next()
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+func (p *parser) parseRecord(mod string) (*record, error) {
+	if err := p.expect("record"); err != nil {
+		return nil, err
+	}
+	name, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	r := &record{module: mod, name: name}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek() != "}" {
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		fname, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		r.fields = append(r.fields, field{fname, typ})
+	}
+	p.next()
+	return r, nil
+}
+
+func (p *parser) parseType() (*juteType, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case "byte", "boolean", "int", "long", "float", "double", "ustring", "buffer":
+		return &juteType{kind: t}, nil
+	case "vector":
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		return &juteType{kind: "vector", elem: elem}, nil
+	case "map":
+		return nil, fmt.Errorf("map is not supported")
+	}
+	return &juteType{kind: "record", name: t}, nil
+}
@@ -52,13 +52,13 @@ func main() {
 				}
 				fmt.Printf("List children of [%d] [%s]: %+v\n", len(children), s[1], children)
 			} else if s[0] == "get" {
-				data, err := conn.Get(s[1])
+				data, _, err := conn.Get(s[1])
 				if err != nil {
 					panic(err)
 				}
 				fmt.Printf("Data of [%s]: %+v\n", s[1], string(data))
 			} else if s[0] == "exist" {
-				flag, err := conn.Exists(s[1])
+				flag, _, err := conn.Exists(s[1])
 				if err != nil {
 					panic(err)
 				}
@@ -78,13 +78,13 @@ func main() {
 			}
 		} else if len(s) == 3 {
 			if s[0] == "create" {
-				err := conn.Create(s[1], []byte(s[2]))
+				_, err := conn.Create(s[1], []byte(s[2]), zk.CreatePersistent, nil)
 				if err != nil {
 					panic(err)
 				}
 				fmt.Printf("[%s] created!\n", s[1])
 			} else if s[0] == "set" {
-				err := conn.Set(s[1], []byte(s[2]))
+				_, err := conn.Set(s[1], []byte(s[2]))
 				if err != nil {
 					panic(err)
 				}
@@ -0,0 +1,6 @@
+// proto为ZooKeeper协议中的记录类型，由jutegen根据zookeeper.jute生成，
+// 包含org.apache.zookeeper.data、proto、txn三个模块。
+// 服务端协议有新增的记录时，更新zookeeper.jute后执行go generate即可。
+package proto
+
+//go:generate go run ../jute/jutegen -package proto -o zookeeper.go zookeeper.jute
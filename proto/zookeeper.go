@@ -0,0 +1,2192 @@
+// Code generated by jutegen from zookeeper.jute. DO NOT EDIT.
+
+package proto
+
+import "github.com/xianmau/gozk/jute"
+
+// org.apache.zookeeper.data.Id
+type Id struct {
+	Scheme string
+	Id     string
+}
+
+func (r *Id) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Scheme)
+	e.WriteString(r.Id)
+	return nil
+}
+
+func (r *Id) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Scheme, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Id, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.data.ACL
+type ACL struct {
+	Perms int32
+	Id    Id
+}
+
+func (r *ACL) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Perms)
+	if err := r.Id.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ACL) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Perms, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if err = r.Id.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.data.Stat
+type Stat struct {
+	Czxid          int64
+	Mzxid          int64
+	Ctime          int64
+	Mtime          int64
+	Version        int32
+	Cversion       int32
+	Aversion       int32
+	EphemeralOwner int64
+	DataLength     int32
+	NumChildren    int32
+	Pzxid          int64
+}
+
+func (r *Stat) Serialize(e *jute.Encoder) error {
+	e.WriteLong(r.Czxid)
+	e.WriteLong(r.Mzxid)
+	e.WriteLong(r.Ctime)
+	e.WriteLong(r.Mtime)
+	e.WriteInt(r.Version)
+	e.WriteInt(r.Cversion)
+	e.WriteInt(r.Aversion)
+	e.WriteLong(r.EphemeralOwner)
+	e.WriteInt(r.DataLength)
+	e.WriteInt(r.NumChildren)
+	e.WriteLong(r.Pzxid)
+	return nil
+}
+
+func (r *Stat) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Czxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Mzxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Ctime, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Mtime, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Cversion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Aversion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.EphemeralOwner, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.DataLength, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.NumChildren, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Pzxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.data.StatPersisted
+type StatPersisted struct {
+	Czxid          int64
+	Mzxid          int64
+	Ctime          int64
+	Mtime          int64
+	Version        int32
+	Cversion       int32
+	Aversion       int32
+	EphemeralOwner int64
+	Pzxid          int64
+}
+
+func (r *StatPersisted) Serialize(e *jute.Encoder) error {
+	e.WriteLong(r.Czxid)
+	e.WriteLong(r.Mzxid)
+	e.WriteLong(r.Ctime)
+	e.WriteLong(r.Mtime)
+	e.WriteInt(r.Version)
+	e.WriteInt(r.Cversion)
+	e.WriteInt(r.Aversion)
+	e.WriteLong(r.EphemeralOwner)
+	e.WriteLong(r.Pzxid)
+	return nil
+}
+
+func (r *StatPersisted) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Czxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Mzxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Ctime, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Mtime, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Cversion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Aversion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.EphemeralOwner, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Pzxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.data.ClientInfo
+type ClientInfo struct {
+	AuthScheme string
+	User       string
+}
+
+func (r *ClientInfo) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.AuthScheme)
+	e.WriteString(r.User)
+	return nil
+}
+
+func (r *ClientInfo) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.AuthScheme, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.User, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ConnectRequest
+type ConnectRequest struct {
+	ProtocolVersion int32
+	LastZxidSeen    int64
+	TimeOut         int32
+	SessionId       int64
+	Passwd          []byte
+	ReadOnly        bool
+}
+
+func (r *ConnectRequest) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.ProtocolVersion)
+	e.WriteLong(r.LastZxidSeen)
+	e.WriteInt(r.TimeOut)
+	e.WriteLong(r.SessionId)
+	e.WriteBuffer(r.Passwd)
+	e.WriteBool(r.ReadOnly)
+	return nil
+}
+
+func (r *ConnectRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.ProtocolVersion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.LastZxidSeen, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.TimeOut, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.SessionId, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Passwd, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	if r.ReadOnly, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ConnectResponse
+type ConnectResponse struct {
+	ProtocolVersion int32
+	TimeOut         int32
+	SessionId       int64
+	Passwd          []byte
+	ReadOnly        bool
+}
+
+func (r *ConnectResponse) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.ProtocolVersion)
+	e.WriteInt(r.TimeOut)
+	e.WriteLong(r.SessionId)
+	e.WriteBuffer(r.Passwd)
+	e.WriteBool(r.ReadOnly)
+	return nil
+}
+
+func (r *ConnectResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.ProtocolVersion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.TimeOut, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.SessionId, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Passwd, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	if r.ReadOnly, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetWatches
+type SetWatches struct {
+	RelativeZxid int64
+	DataWatches  []string
+	ExistWatches []string
+	ChildWatches []string
+}
+
+func (r *SetWatches) Serialize(e *jute.Encoder) error {
+	e.WriteLong(r.RelativeZxid)
+	if r.DataWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.DataWatches)))
+		for i1 := range r.DataWatches {
+			e.WriteString(r.DataWatches[i1])
+		}
+	}
+	if r.ExistWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.ExistWatches)))
+		for i1 := range r.ExistWatches {
+			e.WriteString(r.ExistWatches[i1])
+		}
+	}
+	if r.ChildWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.ChildWatches)))
+		for i1 := range r.ChildWatches {
+			e.WriteString(r.ChildWatches[i1])
+		}
+	}
+	return nil
+}
+
+func (r *SetWatches) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.RelativeZxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.DataWatches = nil
+		} else {
+			r.DataWatches = make([]string, n1)
+			for i1 := range r.DataWatches {
+				if r.DataWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.ExistWatches = nil
+		} else {
+			r.ExistWatches = make([]string, n1)
+			for i1 := range r.ExistWatches {
+				if r.ExistWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.ChildWatches = nil
+		} else {
+			r.ChildWatches = make([]string, n1)
+			for i1 := range r.ChildWatches {
+				if r.ChildWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetWatches2
+type SetWatches2 struct {
+	RelativeZxid               int64
+	DataWatches                []string
+	ExistWatches               []string
+	ChildWatches               []string
+	PersistentWatches          []string
+	PersistentRecursiveWatches []string
+}
+
+func (r *SetWatches2) Serialize(e *jute.Encoder) error {
+	e.WriteLong(r.RelativeZxid)
+	if r.DataWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.DataWatches)))
+		for i1 := range r.DataWatches {
+			e.WriteString(r.DataWatches[i1])
+		}
+	}
+	if r.ExistWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.ExistWatches)))
+		for i1 := range r.ExistWatches {
+			e.WriteString(r.ExistWatches[i1])
+		}
+	}
+	if r.ChildWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.ChildWatches)))
+		for i1 := range r.ChildWatches {
+			e.WriteString(r.ChildWatches[i1])
+		}
+	}
+	if r.PersistentWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.PersistentWatches)))
+		for i1 := range r.PersistentWatches {
+			e.WriteString(r.PersistentWatches[i1])
+		}
+	}
+	if r.PersistentRecursiveWatches == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.PersistentRecursiveWatches)))
+		for i1 := range r.PersistentRecursiveWatches {
+			e.WriteString(r.PersistentRecursiveWatches[i1])
+		}
+	}
+	return nil
+}
+
+func (r *SetWatches2) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.RelativeZxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.DataWatches = nil
+		} else {
+			r.DataWatches = make([]string, n1)
+			for i1 := range r.DataWatches {
+				if r.DataWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.ExistWatches = nil
+		} else {
+			r.ExistWatches = make([]string, n1)
+			for i1 := range r.ExistWatches {
+				if r.ExistWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.ChildWatches = nil
+		} else {
+			r.ChildWatches = make([]string, n1)
+			for i1 := range r.ChildWatches {
+				if r.ChildWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.PersistentWatches = nil
+		} else {
+			r.PersistentWatches = make([]string, n1)
+			for i1 := range r.PersistentWatches {
+				if r.PersistentWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.PersistentRecursiveWatches = nil
+		} else {
+			r.PersistentRecursiveWatches = make([]string, n1)
+			for i1 := range r.PersistentRecursiveWatches {
+				if r.PersistentRecursiveWatches[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.RequestHeader
+type RequestHeader struct {
+	Xid  int32
+	Type int32
+}
+
+func (r *RequestHeader) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Xid)
+	e.WriteInt(r.Type)
+	return nil
+}
+
+func (r *RequestHeader) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Xid, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.MultiHeader
+type MultiHeader struct {
+	Type int32
+	Done bool
+	Err  int32
+}
+
+func (r *MultiHeader) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Type)
+	e.WriteBool(r.Done)
+	e.WriteInt(r.Err)
+	return nil
+}
+
+func (r *MultiHeader) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Done, err = d.ReadBool(); err != nil {
+		return err
+	}
+	if r.Err, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.AuthPacket
+type AuthPacket struct {
+	Type   int32
+	Scheme string
+	Auth   []byte
+}
+
+func (r *AuthPacket) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Type)
+	e.WriteString(r.Scheme)
+	e.WriteBuffer(r.Auth)
+	return nil
+}
+
+func (r *AuthPacket) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Scheme, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Auth, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ReplyHeader
+type ReplyHeader struct {
+	Xid  int32
+	Zxid int64
+	Err  int32
+}
+
+func (r *ReplyHeader) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Xid)
+	e.WriteLong(r.Zxid)
+	e.WriteInt(r.Err)
+	return nil
+}
+
+func (r *ReplyHeader) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Xid, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Zxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Err, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetDataRequest
+type GetDataRequest struct {
+	Path  string
+	Watch bool
+}
+
+func (r *GetDataRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBool(r.Watch)
+	return nil
+}
+
+func (r *GetDataRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Watch, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetDataRequest
+type SetDataRequest struct {
+	Path    string
+	Data    []byte
+	Version int32
+}
+
+func (r *SetDataRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *SetDataRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ReconfigRequest
+type ReconfigRequest struct {
+	JoiningServers string
+	LeavingServers string
+	NewMembers     string
+	CurConfigId    int64
+}
+
+func (r *ReconfigRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.JoiningServers)
+	e.WriteString(r.LeavingServers)
+	e.WriteString(r.NewMembers)
+	e.WriteLong(r.CurConfigId)
+	return nil
+}
+
+func (r *ReconfigRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.JoiningServers, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.LeavingServers, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.NewMembers, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.CurConfigId, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetDataResponse
+type SetDataResponse struct {
+	Stat Stat
+}
+
+func (r *SetDataResponse) Serialize(e *jute.Encoder) error {
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SetDataResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetSASLRequest
+type GetSASLRequest struct {
+	Token []byte
+}
+
+func (r *GetSASLRequest) Serialize(e *jute.Encoder) error {
+	e.WriteBuffer(r.Token)
+	return nil
+}
+
+func (r *GetSASLRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Token, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetSASLRequest
+type SetSASLRequest struct {
+	Token []byte
+}
+
+func (r *SetSASLRequest) Serialize(e *jute.Encoder) error {
+	e.WriteBuffer(r.Token)
+	return nil
+}
+
+func (r *SetSASLRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Token, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetSASLResponse
+type SetSASLResponse struct {
+	Token []byte
+}
+
+func (r *SetSASLResponse) Serialize(e *jute.Encoder) error {
+	e.WriteBuffer(r.Token)
+	return nil
+}
+
+func (r *SetSASLResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Token, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.CreateRequest
+type CreateRequest struct {
+	Path  string
+	Data  []byte
+	Acl   []ACL
+	Flags int32
+}
+
+func (r *CreateRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.Flags)
+	return nil
+}
+
+func (r *CreateRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Flags, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.CreateTTLRequest
+type CreateTTLRequest struct {
+	Path  string
+	Data  []byte
+	Acl   []ACL
+	Flags int32
+	Ttl   int64
+}
+
+func (r *CreateTTLRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.Flags)
+	e.WriteLong(r.Ttl)
+	return nil
+}
+
+func (r *CreateTTLRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Flags, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Ttl, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.DeleteRequest
+type DeleteRequest struct {
+	Path    string
+	Version int32
+}
+
+func (r *DeleteRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *DeleteRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetChildrenRequest
+type GetChildrenRequest struct {
+	Path  string
+	Watch bool
+}
+
+func (r *GetChildrenRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBool(r.Watch)
+	return nil
+}
+
+func (r *GetChildrenRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Watch, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetAllChildrenNumberRequest
+type GetAllChildrenNumberRequest struct {
+	Path string
+}
+
+func (r *GetAllChildrenNumberRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *GetAllChildrenNumberRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetChildren2Request
+type GetChildren2Request struct {
+	Path  string
+	Watch bool
+}
+
+func (r *GetChildren2Request) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBool(r.Watch)
+	return nil
+}
+
+func (r *GetChildren2Request) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Watch, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.CheckVersionRequest
+type CheckVersionRequest struct {
+	Path    string
+	Version int32
+}
+
+func (r *CheckVersionRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *CheckVersionRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetMaxChildrenRequest
+type GetMaxChildrenRequest struct {
+	Path string
+}
+
+func (r *GetMaxChildrenRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *GetMaxChildrenRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetMaxChildrenResponse
+type GetMaxChildrenResponse struct {
+	Max int32
+}
+
+func (r *GetMaxChildrenResponse) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Max)
+	return nil
+}
+
+func (r *GetMaxChildrenResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Max, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetMaxChildrenRequest
+type SetMaxChildrenRequest struct {
+	Path string
+	Max  int32
+}
+
+func (r *SetMaxChildrenRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Max)
+	return nil
+}
+
+func (r *SetMaxChildrenRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Max, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SyncRequest
+type SyncRequest struct {
+	Path string
+}
+
+func (r *SyncRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *SyncRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SyncResponse
+type SyncResponse struct {
+	Path string
+}
+
+func (r *SyncResponse) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *SyncResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetACLRequest
+type GetACLRequest struct {
+	Path string
+}
+
+func (r *GetACLRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *GetACLRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetACLRequest
+type SetACLRequest struct {
+	Path    string
+	Acl     []ACL
+	Version int32
+}
+
+func (r *SetACLRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *SetACLRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.SetACLResponse
+type SetACLResponse struct {
+	Stat Stat
+}
+
+func (r *SetACLResponse) Serialize(e *jute.Encoder) error {
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *SetACLResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.AddWatchRequest
+type AddWatchRequest struct {
+	Path string
+	Mode int32
+}
+
+func (r *AddWatchRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Mode)
+	return nil
+}
+
+func (r *AddWatchRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Mode, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.WatcherEvent
+type WatcherEvent struct {
+	Type  int32
+	State int32
+	Path  string
+}
+
+func (r *WatcherEvent) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Type)
+	e.WriteInt(r.State)
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *WatcherEvent) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.State, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ErrorResponse
+type ErrorResponse struct {
+	Err int32
+}
+
+func (r *ErrorResponse) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Err)
+	return nil
+}
+
+func (r *ErrorResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Err, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.CreateResponse
+type CreateResponse struct {
+	Path string
+}
+
+func (r *CreateResponse) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *CreateResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.Create2Response
+type Create2Response struct {
+	Path string
+	Stat Stat
+}
+
+func (r *Create2Response) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Create2Response) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ExistsRequest
+type ExistsRequest struct {
+	Path  string
+	Watch bool
+}
+
+func (r *ExistsRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBool(r.Watch)
+	return nil
+}
+
+func (r *ExistsRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Watch, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.ExistsResponse
+type ExistsResponse struct {
+	Stat Stat
+}
+
+func (r *ExistsResponse) Serialize(e *jute.Encoder) error {
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ExistsResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetDataResponse
+type GetDataResponse struct {
+	Data []byte
+	Stat Stat
+}
+
+func (r *GetDataResponse) Serialize(e *jute.Encoder) error {
+	e.WriteBuffer(r.Data)
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *GetDataResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetChildrenResponse
+type GetChildrenResponse struct {
+	Children []string
+}
+
+func (r *GetChildrenResponse) Serialize(e *jute.Encoder) error {
+	if r.Children == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Children)))
+		for i1 := range r.Children {
+			e.WriteString(r.Children[i1])
+		}
+	}
+	return nil
+}
+
+func (r *GetChildrenResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Children = nil
+		} else {
+			r.Children = make([]string, n1)
+			for i1 := range r.Children {
+				if r.Children[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetAllChildrenNumberResponse
+type GetAllChildrenNumberResponse struct {
+	TotalNumber int32
+}
+
+func (r *GetAllChildrenNumberResponse) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.TotalNumber)
+	return nil
+}
+
+func (r *GetAllChildrenNumberResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.TotalNumber, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetChildren2Response
+type GetChildren2Response struct {
+	Children []string
+	Stat     Stat
+}
+
+func (r *GetChildren2Response) Serialize(e *jute.Encoder) error {
+	if r.Children == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Children)))
+		for i1 := range r.Children {
+			e.WriteString(r.Children[i1])
+		}
+	}
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *GetChildren2Response) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Children = nil
+		} else {
+			r.Children = make([]string, n1)
+			for i1 := range r.Children {
+				if r.Children[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetACLResponse
+type GetACLResponse struct {
+	Acl  []ACL
+	Stat Stat
+}
+
+func (r *GetACLResponse) Serialize(e *jute.Encoder) error {
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.Stat.Serialize(e); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *GetACLResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err = r.Stat.Deserialize(d); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.CheckWatchesRequest
+type CheckWatchesRequest struct {
+	Path string
+	Type int32
+}
+
+func (r *CheckWatchesRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Type)
+	return nil
+}
+
+func (r *CheckWatchesRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.RemoveWatchesRequest
+type RemoveWatchesRequest struct {
+	Path string
+	Type int32
+}
+
+func (r *RemoveWatchesRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Type)
+	return nil
+}
+
+func (r *RemoveWatchesRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetEphemeralsRequest
+type GetEphemeralsRequest struct {
+	PrefixPath string
+}
+
+func (r *GetEphemeralsRequest) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.PrefixPath)
+	return nil
+}
+
+func (r *GetEphemeralsRequest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.PrefixPath, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.proto.GetEphemeralsResponse
+type GetEphemeralsResponse struct {
+	Ephemerals []string
+}
+
+func (r *GetEphemeralsResponse) Serialize(e *jute.Encoder) error {
+	if r.Ephemerals == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Ephemerals)))
+		for i1 := range r.Ephemerals {
+			e.WriteString(r.Ephemerals[i1])
+		}
+	}
+	return nil
+}
+
+func (r *GetEphemeralsResponse) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Ephemerals = nil
+		} else {
+			r.Ephemerals = make([]string, n1)
+			for i1 := range r.Ephemerals {
+				if r.Ephemerals[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.TxnDigest
+type TxnDigest struct {
+	Version    int32
+	TreeDigest int64
+}
+
+func (r *TxnDigest) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Version)
+	e.WriteLong(r.TreeDigest)
+	return nil
+}
+
+func (r *TxnDigest) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.TreeDigest, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.TxnHeader
+type TxnHeader struct {
+	ClientId int64
+	Cxid     int32
+	Zxid     int64
+	Time     int64
+	Type     int32
+}
+
+func (r *TxnHeader) Serialize(e *jute.Encoder) error {
+	e.WriteLong(r.ClientId)
+	e.WriteInt(r.Cxid)
+	e.WriteLong(r.Zxid)
+	e.WriteLong(r.Time)
+	e.WriteInt(r.Type)
+	return nil
+}
+
+func (r *TxnHeader) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.ClientId, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Cxid, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Zxid, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Time, err = d.ReadLong(); err != nil {
+		return err
+	}
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CreateTxnV0
+type CreateTxnV0 struct {
+	Path      string
+	Data      []byte
+	Acl       []ACL
+	Ephemeral bool
+}
+
+func (r *CreateTxnV0) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteBool(r.Ephemeral)
+	return nil
+}
+
+func (r *CreateTxnV0) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Ephemeral, err = d.ReadBool(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CreateTxn
+type CreateTxn struct {
+	Path           string
+	Data           []byte
+	Acl            []ACL
+	Ephemeral      bool
+	ParentCVersion int32
+}
+
+func (r *CreateTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteBool(r.Ephemeral)
+	e.WriteInt(r.ParentCVersion)
+	return nil
+}
+
+func (r *CreateTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Ephemeral, err = d.ReadBool(); err != nil {
+		return err
+	}
+	if r.ParentCVersion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CreateTTLTxn
+type CreateTTLTxn struct {
+	Path           string
+	Data           []byte
+	Acl            []ACL
+	ParentCVersion int32
+	Ttl            int64
+}
+
+func (r *CreateTTLTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.ParentCVersion)
+	e.WriteLong(r.Ttl)
+	return nil
+}
+
+func (r *CreateTTLTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.ParentCVersion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Ttl, err = d.ReadLong(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CreateContainerTxn
+type CreateContainerTxn struct {
+	Path           string
+	Data           []byte
+	Acl            []ACL
+	ParentCVersion int32
+}
+
+func (r *CreateContainerTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.ParentCVersion)
+	return nil
+}
+
+func (r *CreateContainerTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.ParentCVersion, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.DeleteTxn
+type DeleteTxn struct {
+	Path string
+}
+
+func (r *DeleteTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	return nil
+}
+
+func (r *DeleteTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.SetDataTxn
+type SetDataTxn struct {
+	Path    string
+	Data    []byte
+	Version int32
+}
+
+func (r *SetDataTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteBuffer(r.Data)
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *SetDataTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CheckVersionTxn
+type CheckVersionTxn struct {
+	Path    string
+	Version int32
+}
+
+func (r *CheckVersionTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *CheckVersionTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.SetACLTxn
+type SetACLTxn struct {
+	Path    string
+	Acl     []ACL
+	Version int32
+}
+
+func (r *SetACLTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	if r.Acl == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Acl)))
+		for i1 := range r.Acl {
+			if err := r.Acl[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	e.WriteInt(r.Version)
+	return nil
+}
+
+func (r *SetACLTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Acl = nil
+		} else {
+			r.Acl = make([]ACL, n1)
+			for i1 := range r.Acl {
+				if err = r.Acl[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if r.Version, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.SetMaxChildrenTxn
+type SetMaxChildrenTxn struct {
+	Path string
+	Max  int32
+}
+
+func (r *SetMaxChildrenTxn) Serialize(e *jute.Encoder) error {
+	e.WriteString(r.Path)
+	e.WriteInt(r.Max)
+	return nil
+}
+
+func (r *SetMaxChildrenTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Path, err = d.ReadString(); err != nil {
+		return err
+	}
+	if r.Max, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CreateSessionTxn
+type CreateSessionTxn struct {
+	TimeOut int32
+}
+
+func (r *CreateSessionTxn) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.TimeOut)
+	return nil
+}
+
+func (r *CreateSessionTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.TimeOut, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.CloseSessionTxn
+type CloseSessionTxn struct {
+	Paths2Delete []string
+}
+
+func (r *CloseSessionTxn) Serialize(e *jute.Encoder) error {
+	if r.Paths2Delete == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Paths2Delete)))
+		for i1 := range r.Paths2Delete {
+			e.WriteString(r.Paths2Delete[i1])
+		}
+	}
+	return nil
+}
+
+func (r *CloseSessionTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Paths2Delete = nil
+		} else {
+			r.Paths2Delete = make([]string, n1)
+			for i1 := range r.Paths2Delete {
+				if r.Paths2Delete[i1], err = d.ReadString(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.ErrorTxn
+type ErrorTxn struct {
+	Err int32
+}
+
+func (r *ErrorTxn) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Err)
+	return nil
+}
+
+func (r *ErrorTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Err, err = d.ReadInt(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.Txn
+type Txn struct {
+	Type int32
+	Data []byte
+}
+
+func (r *Txn) Serialize(e *jute.Encoder) error {
+	e.WriteInt(r.Type)
+	e.WriteBuffer(r.Data)
+	return nil
+}
+
+func (r *Txn) Deserialize(d *jute.Decoder) error {
+	var err error
+	if r.Type, err = d.ReadInt(); err != nil {
+		return err
+	}
+	if r.Data, err = d.ReadBuffer(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// org.apache.zookeeper.txn.MultiTxn
+type MultiTxn struct {
+	Txns []Txn
+}
+
+func (r *MultiTxn) Serialize(e *jute.Encoder) error {
+	if r.Txns == nil {
+		e.WriteInt(-1)
+	} else {
+		e.WriteInt(int32(len(r.Txns)))
+		for i1 := range r.Txns {
+			if err := r.Txns[i1].Serialize(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *MultiTxn) Deserialize(d *jute.Decoder) error {
+	var err error
+	{
+		var n1 int
+		if n1, err = d.ReadVectorLen(); err != nil {
+			return err
+		}
+		if n1 == -1 {
+			r.Txns = nil
+		} else {
+			r.Txns = make([]Txn, n1)
+			for i1 := range r.Txns {
+				if err = r.Txns[i1].Deserialize(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
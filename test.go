@@ -44,7 +44,7 @@ func traverse(conn *zk.ZkCli, path string) {
 	}
 
 	if len(children) <= 0 {
-		flag, err := conn.Exists(path)
+		flag, _, err := conn.Exists(path)
 		if err == nil {
 			fmt.Println(path, flag)
 		}
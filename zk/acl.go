@@ -1,5 +1,12 @@
 package zk
 
+import (
+	"crypto/sha1"
+	"encoding/base64"
+
+	"github.com/xianmau/gozk/proto"
+)
+
 const (
 	PermRead   = 1  // 可获取当前节点的数据及所有子节点
 	PermWrite  = 2  // 可向当前节点写数据
@@ -18,3 +25,31 @@ type ACL struct {
 	Scheme string
 	Id     string
 }
+
+// 计算digest方案的ID，格式为 user:base64(sha1(user:password))
+func DigestId(user, password string) string {
+	h := sha1.New()
+	h.Write([]byte(user + ":" + password))
+	return user + ":" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 生成digest方案的ACL，配合AddAuth("digest", []byte(user + ":" + password))使用
+func DigestACL(perms int32, user, password string) []ACL {
+	return []ACL{{perms, "digest", DigestId(user, password)}}
+}
+
+func toProtoACL(acl []ACL) []proto.ACL {
+	res := make([]proto.ACL, len(acl))
+	for i, a := range acl {
+		res[i] = proto.ACL{Perms: a.Perms, Id: proto.Id{Scheme: a.Scheme, Id: a.Id}}
+	}
+	return res
+}
+
+func fromProtoACL(acl []proto.ACL) []ACL {
+	res := make([]ACL, len(acl))
+	for i, a := range acl {
+		res[i] = ACL{a.Perms, a.Id.Scheme, a.Id.Id}
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package zk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestACL(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	// 不指定时使用WorldACL
+	if _, err := cli.Create("/open", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	acl, _, err := cli.GetACL("/open")
+	if err != nil || !reflect.DeepEqual(acl, WorldACL) {
+		t.Fatalf("GetACL default: %+v, %v", acl, err)
+	}
+
+	want := append(DigestACL(PermAll, "user", "secret"), ACL{PermRead, "world", "anyone"})
+	if _, err := cli.Create("/private", nil, CreatePersistent, want); err != nil {
+		t.Fatal(err)
+	}
+	acl, stat, err := cli.GetACL("/private")
+	if err != nil || !reflect.DeepEqual(acl, want) || stat.Aversion != 0 {
+		t.Fatalf("GetACL: %+v, %+v, %v", acl, stat, err)
+	}
+
+	stat, err = cli.SetACL("/private", WorldACL, 0)
+	if err != nil || stat.Aversion != 1 {
+		t.Fatalf("SetACL: %+v, %v", stat, err)
+	}
+	if _, err := cli.SetACL("/private", want, 0); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("SetACL stale version: got %v, want ErrBadVersion", err)
+	}
+	if _, err := cli.SetACL("/private", nil, -1); !errors.Is(err, ErrInvalidACL) {
+		t.Fatalf("SetACL empty: got %v, want ErrInvalidACL", err)
+	}
+	if _, _, err := cli.GetACL("/missing"); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("GetACL missing: got %v, want ErrNoNode", err)
+	}
+	acl, _, _ = cli.GetACL("/private")
+	if !reflect.DeepEqual(acl, WorldACL) {
+		t.Fatalf("ACL after SetACL: %+v", acl)
+	}
+}
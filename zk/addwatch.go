@@ -0,0 +1,45 @@
+package zk
+
+import (
+	"context"
+
+	"github.com/xianmau/gozk/proto"
+)
+
+const (
+	addWatchModePersistent          = 0
+	addWatchModePersistentRecursive = 1
+)
+
+func (zkCli *ZkCli) addWatch(ctx context.Context, path string, recursive bool) (<-chan Event, error) {
+	mode, wType := int32(addWatchModePersistent), watchTypePersistent
+	if recursive {
+		mode, wType = addWatchModePersistentRecursive, watchTypePersistentRecursive
+	}
+	w := newPersistentWatcher(path, wType)
+	req, err := zkCli.newRequest(opAddWatch, &proto.AddWatchRequest{Path: zkCli.serverPath(path), Mode: mode}, w)
+	if err != nil {
+		return nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return nil, codeToError(req.resheader.Err)
+		}
+		return w.ch, nil
+	}
+	return nil, req.err
+}
+
+// API：添加持久监视，触发后不需要重新登记，节点不存在时同样可以添加
+// recursive为false时监视节点本身及其子节点列表的变化，为true时监视整棵子树上节点的创建、删除及数据改变
+// 返回的通道在监视被RemoveWatches删除或会话结束时关闭，应及时读取，需要服务端3.6以上
+// 重连后会自动重新登记，但断开期间发生的变化不会补发
+func (zk *ZkCli) AddWatch(path string, recursive bool) (<-chan Event, error) {
+	return zk.addWatch(context.Background(), path, recursive)
+}
+
+// API：添加持久监视，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) AddWatchContext(ctx context.Context, path string, recursive bool) (<-chan Event, error) {
+	return zk.addWatch(ctx, path, recursive)
+}
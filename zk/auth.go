@@ -0,0 +1,99 @@
+package zk
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+// 已认证成功的凭证，重连后需要重新发送
+type authInfo struct {
+	scheme string
+	auth   []byte
+}
+
+func (zkCli *ZkCli) addAuth(scheme string, auth []byte) error {
+	// 认证请求的xid固定为-4，同一时间只能有一个认证请求
+	zkCli.authReqLock.Lock()
+	defer zkCli.authReqLock.Unlock()
+	buf, err := encodeRequest(-4, opAuth, &proto.AuthPacket{Type: 0, Scheme: scheme, Auth: auth})
+	if err != nil {
+		return err
+	}
+	req := &request{
+		xid:    -4,
+		opcode: opAuth,
+		reqbuf: buf,
+		resbuf: nil,
+		err:    nil,
+		done:   make(chan bool, 1),
+	}
+	// 认证请求的响应没有唯一的xid，不能中途放弃等待
+	zkCli.sendRequest(context.Background(), req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			if req.resheader.Err == errAuthFailed {
+				zkCli.setState(SessionAuthFailed)
+			}
+			return codeToError(req.resheader.Err)
+		}
+		zkCli.authLock.Lock()
+		zkCli.auths = append(zkCli.auths, authInfo{scheme, auth})
+		zkCli.authLock.Unlock()
+		return nil
+	}
+	return req.err
+}
+
+// 在新建立的连接上重新发送所有凭证，此时收发循环还未启动，直接读写连接
+func (zkCli *ZkCli) resendAuths() error {
+	zkCli.authLock.Lock()
+	auths := make([]authInfo, len(zkCli.auths))
+	copy(auths, zkCli.auths)
+	zkCli.authLock.Unlock()
+
+	pkgSizeBuf := make([]byte, 4)
+	for _, info := range auths {
+		buf, err := encodeRequest(-4, opAuth, &proto.AuthPacket{Type: 0, Scheme: info.scheme, Auth: info.auth})
+		if err != nil {
+			return err
+		}
+		zkCli.conn.SetWriteDeadline(time.Now().Add(zkCli.writeTimeout))
+		_, err = zkCli.conn.Write(buf)
+		zkCli.conn.SetWriteDeadline(time.Time{})
+		if err != nil {
+			return err
+		}
+		zkCli.conn.SetReadDeadline(time.Now().Add(zkCli.readTimeout))
+		_, err = io.ReadFull(zkCli.conn, pkgSizeBuf)
+		if err == nil {
+			pkgSize := BytesToInt32(pkgSizeBuf)
+			if pkgSize < 0 || pkgSize > zkCli.maxPacketSize {
+				err = ErrMarshallingError
+			} else {
+				buf = make([]byte, pkgSize)
+				_, err = io.ReadFull(zkCli.conn, buf)
+			}
+		}
+		zkCli.conn.SetReadDeadline(time.Time{})
+		if err != nil {
+			return err
+		}
+		resHeader := &proto.ReplyHeader{}
+		if _, err := jute.Unmarshal(buf, resHeader); err != nil {
+			return err
+		}
+		if resHeader.Err != errOk {
+			return codeToError(resHeader.Err)
+		}
+	}
+	return nil
+}
+
+// API：添加认证信息，如AddAuth("digest", []byte("user:password"))
+func (zk *ZkCli) AddAuth(scheme string, auth []byte) error {
+	return zk.addAuth(scheme, auth)
+}
@@ -0,0 +1,73 @@
+package zk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDigestId(t *testing.T) {
+	// 与ZooKeeper的DigestAuthenticationProvider.generateDigest结果一致
+	if id := DigestId("super", "test"); id != "super:D/InIHSb7yEEbrWz8b9l71RjZJU=" {
+		t.Fatalf("DigestId: %s", id)
+	}
+	acl := DigestACL(PermRead|PermWrite, "super", "test")
+	if len(acl) != 1 || acl[0] != (ACL{PermRead | PermWrite, "digest", "super:D/InIHSb7yEEbrWz8b9l71RjZJU="}) {
+		t.Fatalf("DigestACL: %+v", acl)
+	}
+}
+
+func TestAddAuth(t *testing.T) {
+	srv := newServer(t)
+	srv.SetDigestUser("user", "secret")
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+
+	if err := cli.AddAuth("digest", []byte("user:wrong")); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("AddAuth with a wrong password: got %v, want ErrAuthFailed", err)
+	}
+	wait(t, SessionAuthFailed)
+	if state := cli.State(); state != SessionConnected {
+		t.Fatalf("state after a failed AddAuth: %v", state)
+	}
+
+	if err := cli.AddAuth("digest", []byte("user:secret")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"digest:" + DigestId("user", "secret")}
+	if auths := srv.Auths(cli.SessionID()); !reflect.DeepEqual(auths, want) {
+		t.Fatalf("server auths: %v, want %v", auths, want)
+	}
+
+	// 重连后只重新发送认证成功的凭证
+	srv.Disconnect(cli.SessionID())
+	wait(t, SessionReconnected)
+	if auths := srv.Auths(cli.SessionID()); !reflect.DeepEqual(auths, want) {
+		t.Fatalf("server auths after reconnect: %v, want %v", auths, want)
+	}
+	if _, _, err := cli.Get("/"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 重连时重新认证失败，报告SessionAuthFailed且不使用这个连接
+func TestResendAuthFailed(t *testing.T) {
+	srv := newServer(t)
+	srv.SetDigestUser("user", "secret")
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+
+	if err := cli.AddAuth("digest", []byte("user:secret")); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetDigestUser("user", "changed")
+	srv.Disconnect(cli.SessionID())
+	wait(t, SessionSuspended)
+	wait(t, SessionAuthFailed)
+	if state := cli.State(); state != SessionSuspended {
+		t.Fatalf("state: %v", state)
+	}
+	if auths := srv.Auths(cli.SessionID()); len(auths) != 0 {
+		t.Fatalf("server auths: %v", auths)
+	}
+}
@@ -1,72 +1,79 @@
 package zk
 
-type childrenRequest struct {
-	xid    int32
-	opcode int32
-	path   string
-	watch  bool
-}
+import (
+	"context"
 
-func encodeChildrenRequest(buf []byte, req *childrenRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[12:], path_len)
-	copy(buf[16:], req.path)
-	BoolToBytes(buf[16+path_len:], req.watch)
-	Int32ToBytes(buf[0:], 13+path_len)
-	return 17 + path_len
-}
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
 
-type childrenResponse struct {
-	children []string
-}
-
-func decodeChildrenResponse(buf []byte, res *childrenResponse) {
-	children_cnt := BytesToInt32(buf)
-	for n := 4; children_cnt > 0; children_cnt-- {
-		child_len := int(BytesToInt32(buf[n:]))
-		n += 4
-		res.children = append(res.children, string(buf[n:n+child_len]))
-		n += child_len
+// opcode为opChildren时不返回节点状态，为opChildren2时返回
+func (zkCli *ZkCli) children(ctx context.Context, path string, watch bool, opcode int32) ([]string, *Stat, <-chan Event, error) {
+	var w *watcher
+	if watch {
+		w = newWatcher(path, watchTypeChild)
 	}
-}
-
-func (zkCli *ZkCli) children(path string) ([]string, error) {
-	buf := make([]byte, BufferSize)
-	n := encodeChildrenRequest(buf, &childrenRequest{
-		xid:    zkCli.getNextXid(),
-		opcode: opChildren,
-		path:   path,
-		watch:  false,
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opChildren,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
+	req, err := zkCli.newRequest(opcode, &proto.GetChildrenRequest{Path: zkCli.serverPath(path), Watch: watch}, w)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
+	zkCli.sendRequest(ctx, req)
 	if req.err == nil {
-		res := &childrenResponse{
-			children: []string{},
+		if req.resheader.Err != errOk {
+			return nil, nil, nil, codeToError(req.resheader.Err)
+		}
+		var children []string
+		var stat *Stat
+		if opcode == opChildren2 {
+			res := &proto.GetChildren2Response{}
+			_, err = jute.Unmarshal(req.resbuf, res)
+			s := Stat(res.Stat)
+			children, stat = res.Children, &s
+		} else {
+			res := &proto.GetChildrenResponse{}
+			_, err = jute.Unmarshal(req.resbuf, res)
+			children = res.Children
+		}
+		if err != nil {
+			return nil, nil, nil, err
 		}
-		decodeChildrenResponse(req.resbuf, res)
-		//logger.Println(req.resbuf)
-		return res.children, nil
+		if w != nil {
+			return children, stat, w.ch, nil
+		}
+		return children, stat, nil, nil
 	}
-	// 这里应该判断各种错误
-	return nil, req.err
+	return nil, nil, nil, req.err
 }
 
 // API：获取子节点列表
 func (zk *ZkCli) Children(path string) ([]string, error) {
-	return zk.children(path)
+	return zk.ChildrenContext(context.Background(), path)
+}
+
+// API：获取子节点列表，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) ChildrenContext(ctx context.Context, path string) ([]string, error) {
+	children, _, _, err := zk.children(ctx, path, false, opChildren)
+	return children, err
+}
+
+// API：获取子节点列表，并监视子节点的变化
+func (zk *ZkCli) ChildrenW(path string) ([]string, <-chan Event, error) {
+	return zk.ChildrenWContext(context.Background(), path)
+}
+
+// API：获取子节点列表，并监视子节点的变化，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) ChildrenWContext(ctx context.Context, path string) ([]string, <-chan Event, error) {
+	children, _, ch, err := zk.children(ctx, path, true, opChildren)
+	return children, ch, err
+}
+
+// API：获取子节点列表及当前节点的状态
+func (zk *ZkCli) Children2(path string) ([]string, *Stat, error) {
+	return zk.Children2Context(context.Background(), path)
+}
+
+// API：获取子节点列表及当前节点的状态，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) Children2Context(ctx context.Context, path string) ([]string, *Stat, error) {
+	children, stat, _, err := zk.children(ctx, path, false, opChildren2)
+	return children, stat, err
 }
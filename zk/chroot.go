@@ -0,0 +1,72 @@
+package zk
+
+import (
+	"strings"
+)
+
+// 解析连接串，如"zk1:2181,zk2:2181/app/prod"，返回服务器列表及chroot路径
+// servers的每一项可以是单个地址，也可以是逗号分隔的多个地址，chroot写在最后
+func parseConnectString(servers []string) ([]string, string, error) {
+	connStr := strings.Join(servers, ",")
+	chroot := ""
+	if i := strings.IndexRune(connStr, '/'); i >= 0 {
+		connStr, chroot = connStr[:i], connStr[i:]
+		if chroot == "/" {
+			chroot = "" // 根节点相当于没有chroot
+		} else if !validPath(chroot) {
+			return nil, "", ErrInvalidPath
+		}
+	}
+	var addrs []string
+	for _, addr := range strings.Split(connStr, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, "", ErrBadArguments
+	}
+	return addrs, chroot, nil
+}
+
+// 路径必须以/开头，不能以/结尾（根节点除外），不能有空的节点名及.、..
+func validPath(path string) bool {
+	if path == "/" {
+		return true
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/") {
+		return false
+	}
+	for _, name := range strings.Split(path[1:], "/") {
+		if name == "" || name == "." || name == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// 客户端路径加上chroot前缀，得到服务端上的路径
+func (zkCli *ZkCli) serverPath(path string) string {
+	if zkCli.chroot == "" {
+		return path
+	}
+	if path == "/" {
+		return zkCli.chroot
+	}
+	return zkCli.chroot + path
+}
+
+// 去掉服务端路径的chroot前缀，得到客户端路径
+func (zkCli *ZkCli) clientPath(path string) string {
+	if zkCli.chroot == "" {
+		return path
+	}
+	if path == zkCli.chroot {
+		return "/"
+	}
+	if strings.HasPrefix(path, zkCli.chroot+"/") {
+		return path[len(zkCli.chroot):]
+	}
+	return path
+}
@@ -0,0 +1,92 @@
+package zk
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseConnectString(t *testing.T) {
+	for _, c := range []struct {
+		in      []string
+		servers []string
+		chroot  string
+		err     error
+	}{
+		{[]string{"zk1:2181"}, []string{"zk1:2181"}, "", nil},
+		{[]string{"zk1:2181, zk2:2181/app/prod"}, []string{"zk1:2181", "zk2:2181"}, "/app/prod", nil},
+		{[]string{"zk1", "zk2,zk3/app"}, []string{"zk1", "zk2", "zk3"}, "/app", nil},
+		{[]string{"zk1:2181/"}, []string{"zk1:2181"}, "", nil},
+		{[]string{"zk1:2181/app/"}, nil, "", ErrInvalidPath},
+		{[]string{"zk1:2181/app//prod"}, nil, "", ErrInvalidPath},
+		{[]string{"/app"}, nil, "", ErrBadArguments},
+		{nil, nil, "", ErrBadArguments},
+	} {
+		servers, chroot, err := parseConnectString(c.in)
+		if !errors.Is(err, c.err) || !reflect.DeepEqual(servers, c.servers) || chroot != c.chroot {
+			t.Errorf("%q: got %q, %q, %v, want %q, %q, %v", c.in, servers, chroot, err, c.servers, c.chroot, c.err)
+		}
+	}
+}
+
+func TestChrootPaths(t *testing.T) {
+	cli := &ZkCli{chroot: "/app"}
+	for client, server := range map[string]string{
+		"/":     "/app",
+		"/a":    "/app/a",
+		"/a/b":  "/app/a/b",
+		"/app2": "/app/app2",
+	} {
+		if p := cli.serverPath(client); p != server {
+			t.Errorf("serverPath(%s) = %s, want %s", client, p, server)
+		}
+		if p := cli.clientPath(server); p != client {
+			t.Errorf("clientPath(%s) = %s, want %s", server, p, client)
+		}
+	}
+	// 不在chroot下的路径原样返回
+	if p := cli.clientPath("/application"); p != "/application" {
+		t.Errorf("clientPath(/application) = %s", p)
+	}
+}
+
+func TestChroot(t *testing.T) {
+	srv := newServer(t)
+	root := newClient(t, srv.Addr())
+	for _, p := range []string{"/app", "/app/prod"} {
+		if _, err := root.Create(p, nil, CreatePersistent, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cli := newClient(t, srv.Addr()+","+srv.Addr()+"/app/prod")
+	_, _, ch, err := cli.ExistsW("/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := cli.Create("/x", nil, CreateSequential, nil)
+	if err != nil || path != "/x0000000000" {
+		t.Fatalf("Create under chroot: %q, %v", path, err)
+	}
+	if _, err := cli.Create("/x", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, ch); ev.Type != EventNodeCreated || ev.Path != "/x" {
+		t.Fatalf("event under chroot: %+v", ev)
+	}
+	res, err := cli.Multi().Create("/y", nil, CreatePersistent, nil).Commit()
+	if err != nil || res[0].Path != "/y" {
+		t.Fatalf("multi under chroot: %+v, %v", res, err)
+	}
+	children, err := cli.Children("/")
+	if err != nil || len(children) != 3 {
+		t.Fatalf("Children of the chroot: %v, %v", children, err)
+	}
+	if ok, _, _ := root.Exists("/app/prod/y"); !ok {
+		t.Fatal("node not created inside the chroot")
+	}
+
+	if err := New().Connect([]string{srv.Addr() + "/bad/"}); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("invalid chroot: got %v, want ErrInvalidPath", err)
+	}
+}
@@ -1,49 +1,47 @@
 package zk
 
-type closeRequest struct {
-	xid    int32
-	opcode int32
-}
-
-func encodeCloseRequest(buf []byte, req *closeRequest) int32 {
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[0:], 8)
-	return 12
-}
-
-type closeResponse struct {
-}
-
-func decodeCloseResponse(buf []byte, res *closeResponse) {
-}
+import (
+	"sync/atomic"
+)
 
+// 关闭会话的请求只有请求头
 func (zkCli *ZkCli) close() error {
-	buf := make([]byte, 12)
-	n := encodeCloseRequest(buf, &closeRequest{
-		xid:    zkCli.getNextXid(),
-		opcode: opClose,
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opClose,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
+	req, err := zkCli.newRequest(opClose, nil, nil)
+	if err != nil {
+		return err
 	}
+	// 先标记为已关闭，之后的请求直接返回ErrClosed
 	zkCli.reqLock.Lock()
+	if atomic.LoadInt32(&zkCli.closed) == 1 {
+		zkCli.reqLock.Unlock()
+		return ErrClosed
+	}
+	atomic.StoreInt32(&zkCli.closed, 1)
+	switch zkCli.State() {
+	case SessionConnected, SessionConnectedReadOnly:
+	case SessionDisconnected:
+		// 从未连接成功，连接守护没有运行
+		zkCli.reqLock.Unlock()
+		zkCli.setState(SessionClosed)
+		return nil
+	default:
+		// 正在重连，没有可以发送关闭请求的连接，不等这一轮重连结束，会话由服务端超时后结束
+		zkCli.reqLock.Unlock()
+		close(zkCli.closechan)
+		return nil
+	}
 	zkCli.reqMap[req.xid] = req
 	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
-	if req.err == nil {
-		close(zkCli.sentchan)
-		zkCli.conn.Close()
-		//logger.Println(req.resbuf)
+	select {
+	case zkCli.sentchan <- req:
+		<-req.done
+	case <-req.done:
+	}
+	// 通知连接守护退出，由它关闭连接并结束剩余的请求
+	close(zkCli.closechan)
+	if req.err == nil || req.err == ErrClosed {
 		return nil
 	}
-	// 这里应该判断各种错误
 	return req.err
 }
 
@@ -2,51 +2,165 @@ package zk
 
 import (
 	"errors"
+	"fmt"
 )
 
 const (
-	opCreate   = 1
-	opDelete   = 2
-	opExists   = 3
-	opGet      = 4
-	opSet      = 5
-	opChildren = 8
-	opPing     = 11
-	opClose    = -11
+	opCreate          = 1
+	opDelete          = 2
+	opExists          = 3
+	opGet             = 4
+	opSet             = 5
+	opGetAcl          = 6
+	opSetAcl          = 7
+	opChildren        = 8
+	opSync            = 9
+	opPing            = 11
+	opChildren2       = 12
+	opCheck           = 13
+	opMulti           = 14
+	opCreate2         = 15
+	opCheckWatches    = 17
+	opRemoveWatches   = 18
+	opCreateContainer = 19
+	opCreateTTL       = 21
+	opSetWatches      = 101
+	opSetWatches2     = 105
+	opAddWatch        = 106
+	opAuth            = 100
+	opClose           = -11
 )
 
 const (
-	stateDisconnect = 1
-	stateConnecting = 2
-	stateConnected  = 3
+	errOk                           = 0
+	errSystemError                  = -1
+	errRuntimeInconsistency         = -2
+	errDataInconsistency            = -3
+	errConnectionLoss               = -4
+	errMarshallingError             = -5
+	errUnimplemented                = -6
+	errOperationTimeout             = -7
+	errBadArguments                 = -8
+	errNewConfigNoQuorum            = -13
+	errReconfigInProgress           = -14
+	errUnknownSession               = -15
+	errAPIError                     = -100
+	errNoNode                       = -101
+	errNoAuth                       = -102
+	errBadVersion                   = -103
+	errNoChildrenForEphemerals      = -108
+	errNodeExists                   = -110
+	errNotEmpty                     = -111
+	errSessionExpired               = -112
+	errInvalidCallback              = -113
+	errInvalidAcl                   = -114
+	errAuthFailed                   = -115
+	errClosing                      = -116
+	errNothing                      = -117
+	errSessionMoved                 = -118
+	errNotReadOnly                  = -119
+	errEphemeralOnLocalSession      = -120
+	errNoWatcher                    = -121
+	errRequestTimeout               = -122
+	errReconfigDisabled             = -123
+	errSessionClosedRequireSaslAuth = -124
+	errQuotaExceeded                = -125
+	errThrottled                    = -127
+	errConnectionDisabled           = -200 // 连接不可用
+	errChannelClosed                = -201 // 请求队列关闭
+	errEOF                          = -202 // 读结束
 )
 
-const (
-	errOk                      = 0
-	errAPIError                = -100
-	errNoNode                  = -101
-	errNoAuth                  = -102
-	errBadVersion              = -103
-	errNoChildrenForEphemerals = -108
-	errNodeExists              = -110
-	errNotEmpty                = -111
-	errSessionExpired          = -112
-	errInvalidCallback         = -113
-	errInvalidAcl              = -114
-	errAuthFailed              = -115
-	errClosing                 = -116
-	errNothing                 = -117
-	errSessionMoved            = -118
-	errConnectionDisabled      = -200 // 连接不可用
-	errChannelClosed           = -201 // 请求队列关闭
-	errEOF                     = -202 // 读结束
+// 服务端错误码对应的错误，可直接比较或使用errors.Is判断
+var (
+	ErrSystemError                  = errors.New("zk: system error")
+	ErrRuntimeInconsistency         = errors.New("zk: runtime inconsistency")
+	ErrDataInconsistency            = errors.New("zk: data inconsistency")
+	ErrMarshallingError             = errors.New("zk: marshalling error")
+	ErrUnimplemented                = errors.New("zk: unimplemented operation")
+	ErrOperationTimeout             = errors.New("zk: operation timeout")
+	ErrBadArguments                 = errors.New("zk: bad arguments")
+	ErrNewConfigNoQuorum            = errors.New("zk: no quorum of new config is connected")
+	ErrReconfigInProgress           = errors.New("zk: reconfiguration in progress")
+	ErrUnknownSession               = errors.New("zk: unknown session")
+	ErrAPIError                     = errors.New("zk: api error")
+	ErrNoNode                       = errors.New("zk: node does not exist")                    // 节点不存在
+	ErrNoAuth                       = errors.New("zk: not authenticated")                      // 没有权限
+	ErrBadVersion                   = errors.New("zk: version conflict")                       // 版本号不匹配
+	ErrNoChildrenForEphemerals      = errors.New("zk: ephemeral nodes may not have children")  // 临时节点不能有子节点
+	ErrNodeExists                   = errors.New("zk: node already exists")                    // 节点已存在
+	ErrNotEmpty                     = errors.New("zk: node has children")                      // 节点还有子节点
+	ErrSessionExpired               = errors.New("zk: session has been expired by the server") // 会话过期
+	ErrInvalidCallback              = errors.New("zk: invalid callback specified")
+	ErrInvalidACL                   = errors.New("zk: invalid ACL specified")
+	ErrAuthFailed                   = errors.New("zk: client authentication failed") // 认证失败
+	ErrClosing                      = errors.New("zk: zookeeper is closing")
+	ErrNothing                      = errors.New("zk: no server responses to process")
+	ErrSessionMoved                 = errors.New("zk: session moved to another server, so operation is ignored")
+	ErrNotReadOnly                  = errors.New("zk: state-changing request is passed to read-only server")
+	ErrEphemeralOnLocalSession      = errors.New("zk: attempt to create ephemeral node on a local session")
+	ErrNoWatcher                    = errors.New("zk: the watcher couldn't be found")
+	ErrRequestTimeout               = errors.New("zk: request timeout")
+	ErrReconfigDisabled             = errors.New("zk: reconfig is disabled")
+	ErrSessionClosedRequireSaslAuth = errors.New("zk: session closed because client failed to authenticate")
+	ErrQuotaExceeded                = errors.New("zk: quota exceeded")
+	ErrThrottled                    = errors.New("zk: request throttled")
+)
+
+// 客户端自身的错误
+var (
+	ErrConnectionClosed = errors.New("zk: connection closed")      // 连接断开或还未连接，请求可能已执行也可能未执行
+	ErrClosed           = errors.New("zk: client has been closed") // 客户端已关闭
+	ErrPacketTooLarge   = errors.New("zk: packet too large")       // 包大小超过限制
+	ErrInvalidPath      = errors.New("zk: invalid path")           // 路径不合法
 )
 
 var (
 	errMap = map[int32]error{
-		errOk: nil,
-		errConnectionDisabled: errors.New("zk: connection disabled"),
-		errChannelClosed:      errors.New("zk: channel closed"),
-		errEOF:                errors.New("zk: end of file"),
+		errOk:                           nil,
+		errSystemError:                  ErrSystemError,
+		errRuntimeInconsistency:         ErrRuntimeInconsistency,
+		errDataInconsistency:            ErrDataInconsistency,
+		errConnectionLoss:               ErrConnectionClosed,
+		errMarshallingError:             ErrMarshallingError,
+		errUnimplemented:                ErrUnimplemented,
+		errOperationTimeout:             ErrOperationTimeout,
+		errBadArguments:                 ErrBadArguments,
+		errNewConfigNoQuorum:            ErrNewConfigNoQuorum,
+		errReconfigInProgress:           ErrReconfigInProgress,
+		errUnknownSession:               ErrUnknownSession,
+		errAPIError:                     ErrAPIError,
+		errNoNode:                       ErrNoNode,
+		errNoAuth:                       ErrNoAuth,
+		errBadVersion:                   ErrBadVersion,
+		errNoChildrenForEphemerals:      ErrNoChildrenForEphemerals,
+		errNodeExists:                   ErrNodeExists,
+		errNotEmpty:                     ErrNotEmpty,
+		errSessionExpired:               ErrSessionExpired,
+		errInvalidCallback:              ErrInvalidCallback,
+		errInvalidAcl:                   ErrInvalidACL,
+		errAuthFailed:                   ErrAuthFailed,
+		errClosing:                      ErrClosing,
+		errNothing:                      ErrNothing,
+		errSessionMoved:                 ErrSessionMoved,
+		errNotReadOnly:                  ErrNotReadOnly,
+		errEphemeralOnLocalSession:      ErrEphemeralOnLocalSession,
+		errNoWatcher:                    ErrNoWatcher,
+		errRequestTimeout:               ErrRequestTimeout,
+		errReconfigDisabled:             ErrReconfigDisabled,
+		errSessionClosedRequireSaslAuth: ErrSessionClosedRequireSaslAuth,
+		errQuotaExceeded:                ErrQuotaExceeded,
+		errThrottled:                    ErrThrottled,
+		errConnectionDisabled:           errors.New("zk: connection disabled"),
+		errChannelClosed:                errors.New("zk: channel closed"),
+		errEOF:                          errors.New("zk: end of file"),
 	}
 )
+
+// 把服务端返回的错误码转换成错误
+func codeToError(code int32) error {
+	if err, ok := errMap[code]; ok {
+		return err
+	}
+	return fmt.Errorf("zk: unknown error code %d", code)
+}
@@ -0,0 +1,70 @@
+package zk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCodeToError(t *testing.T) {
+	seen := make(map[error]int32)
+	for code, err := range errMap {
+		if code == errOk {
+			if err != nil {
+				t.Errorf("errOk maps to %v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("code %d maps to nil", code)
+			continue
+		}
+		if other, ok := seen[err]; ok {
+			t.Errorf("codes %d and %d map to the same error %v", code, other, err)
+		}
+		seen[err] = code
+		if codeToError(code) != err {
+			t.Errorf("codeToError(%d) = %v, want %v", code, codeToError(code), err)
+		}
+	}
+	if err := codeToError(-12345); err == nil || !strings.Contains(err.Error(), "-12345") {
+		t.Fatalf("unknown code: %v", err)
+	}
+}
+
+// 服务端返回的错误码转换为可以用errors.Is判断的错误
+func TestServerErrors(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/node", []byte("v1"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Create("/node", nil, CreatePersistent, nil); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("Create existing: got %v, want ErrNodeExists", err)
+	}
+	if _, err := cli.Create("/missing/child", nil, CreatePersistent, nil); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("Create without parent: got %v, want ErrNoNode", err)
+	}
+	if _, err := cli.Create("/node/child", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Delete("/node"); !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("Delete non-empty: got %v, want ErrNotEmpty", err)
+	}
+	if _, err := cli.SetVersion("/node", nil, 5); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("SetVersion: got %v, want ErrBadVersion", err)
+	}
+	if err := cli.DeleteRecur("/node"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cli.Get("/node"); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("Get deleted: got %v, want ErrNoNode", err)
+	}
+	if _, err := cli.Children("/node"); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("Children deleted: got %v, want ErrNoNode", err)
+	}
+	if ok, _, err := cli.Exists("/node"); ok || err != nil {
+		t.Fatalf("Exists deleted: %v, %v", ok, err)
+	}
+}
@@ -1,197 +1,378 @@
 package zk
 
 import (
-	"fmt"
+	"errors"
 	"io"
 	"net"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
-)
-
-type connectRequest struct {
-	protocolversion int32
-	lastzxidseen    int64
-	timeout         int32
-	sessionid       int64
-	password        []byte
-}
-
-func encodeConnectRequest(buf []byte, req *connectRequest) int32 {
-	Int32ToBytes(buf[4:], req.protocolversion)
-	Int64ToBytes(buf[8:], req.lastzxidseen)
-	Int32ToBytes(buf[16:], req.timeout)
-	Int64ToBytes(buf[20:], req.sessionid)
-	Int32ToBytes(buf[28:], 16)
-	copy(buf[32:], req.password)
-	Int32ToBytes(buf[0:], 44)
-	return 48
-}
-
-type connectResponse struct {
-	protocolversion int32
-	timeout         int32
-	sessionid       int64
-	password        []byte
-}
 
-func decodeConnectResponse(buf []byte, res *connectResponse) {
-	res.protocolversion = BytesToInt32(buf)
-	res.timeout = BytesToInt32(buf[4:])
-	res.sessionid = BytesToInt64(buf[8:])
-	n := BytesToInt32(buf[16:])
-	res.password = buf[20 : 20+n]
-}
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
 
-func (zkCli *ZkCli) sentLoop() error {
-	// 设置心跳定时器
-	pingTicker := time.NewTicker(PingInterval * time.Millisecond)
+func (zkCli *ZkCli) sentLoop(stop chan bool) error {
+	// 设置心跳定时器，间隔为协商后会话超时的1/3
+	pingInterval := time.Duration(zkCli.sessiontimeout) * time.Millisecond / 3
+	pingTicker := time.NewTicker(pingInterval)
 	defer pingTicker.Stop()
-	pingBuf := make([]byte, 12)
+	pingBuf, err := encodePingRequest()
+	if err != nil {
+		return err
+	}
 	for {
 		select {
 		case req := <-zkCli.sentchan: // 收到客户端请求
-			zkCli.conn.SetWriteDeadline(time.Now().Add(RecvTimeout * time.Second))
+			zkCli.reqLock.Lock()
+			_, ok := zkCli.reqMap[req.xid]
+			zkCli.reqLock.Unlock()
+			if !ok {
+				// 连接断开时已经结束的请求不再发送
+				continue
+			}
+			zkCli.logDebug("send request", "xid", req.xid, "opcode", req.opcode)
+			zkCli.conn.SetWriteDeadline(time.Now().Add(zkCli.writeTimeout))
 			_, err := zkCli.conn.Write(req.reqbuf)
 			if err != nil {
-				logger.Println(err)
+				zkCli.logDebug("write request failed", "xid", req.xid, "opcode", req.opcode, "err", err)
 				return err
 			}
 			zkCli.conn.SetWriteDeadline(time.Time{})
-
-			if req.opcode == opClose {
-				req.err = nil
-				req.done <- true
-				logger.Println(zkCli.reqMap)
-				return nil
-			}
 		case <-pingTicker.C: // 发送心跳
-			encodePingRequest(pingBuf, &pingRequest{
-				xid:    -2,
-				opcode: opPing,
-			})
-			zkCli.conn.SetWriteDeadline(time.Now().Add(RecvTimeout * time.Second))
+			zkCli.conn.SetWriteDeadline(time.Now().Add(zkCli.writeTimeout))
 			_, err := zkCli.conn.Write(pingBuf)
 			zkCli.conn.SetWriteDeadline(time.Time{})
 			if err != nil {
-				logger.Println(err)
+				zkCli.logDebug("write ping failed", "err", err)
 				return err
 			}
+		case <-stop: // 接收循环已退出
+			return errMap[errConnectionDisabled]
+		case <-zkCli.closechan: // 主动关闭
+			return nil
 		}
 	}
 }
 
-func (zkCli *ZkCli) recvLoop() {
+func (zkCli *ZkCli) recvLoop() error {
+	// 超过会话超时的2/3还没收到任何数据（包括心跳响应），认为服务器已不可用
+	recvTimeout := time.Duration(zkCli.sessiontimeout) * time.Millisecond * 2 / 3
 	pkgSizeBuf := make([]byte, 4)
 	for {
-		zkCli.conn.SetReadDeadline(time.Now().Add(RecvTimeout * time.Second))
+		zkCli.conn.SetReadDeadline(time.Now().Add(recvTimeout))
 		//_, err := zkCli.conn.Read(pkgSizeBuf)
 		_, err := io.ReadFull(zkCli.conn, pkgSizeBuf)
 		zkCli.conn.SetReadDeadline(time.Time{})
 		if err != nil {
-			logger.Println(err)
-			return
+			zkCli.logDebug("read response failed", "err", err)
+			return err
 		}
 		pkgSize := BytesToInt32(pkgSizeBuf)
+		if pkgSize < 16 {
+			// 连响应头都不完整
+			return ErrMarshallingError
+		}
+		if pkgSize > zkCli.maxPacketSize+responseOverhead {
+			// 只让对应的请求失败，连接及其他请求不受影响
+			if err := zkCli.discardResponse(pkgSize); err != nil {
+				return err
+			}
+			continue
+		}
 		pkgBuf := make([]byte, pkgSize)
 		//_, err = zkCli.conn.Read(pkgBuf)
 		_, err = io.ReadFull(zkCli.conn, pkgBuf)
 		if err != nil {
-			logger.Println(err)
-			return
+			zkCli.logDebug("read response failed", "err", err)
+			return err
 		}
 
-		resHeader := &responseHeader{}
-		decodeResponseHeader(pkgBuf[:16], resHeader)
+		resHeader := &proto.ReplyHeader{}
+		jute.Unmarshal(pkgBuf[:16], resHeader)
 
-		if resHeader.xid == -2 {
+		if resHeader.Xid == -2 {
 			// ping pkg
-		} else if resHeader.xid > 0 {
+		} else if resHeader.Xid == setWatchesXid {
+			if resHeader.Err != errOk {
+				zkCli.logWarn("set watches failed", "err", codeToError(resHeader.Err))
+			}
+		} else if resHeader.Xid == -1 {
+			// 事件通知
+			res := &proto.WatcherEvent{}
+			if _, err := jute.Unmarshal(pkgBuf[16:], res); err != nil {
+				zkCli.logError("decode watcher event failed", "err", err)
+				return err
+			}
+			if res.Type == int32(EventNone) && res.State == int32(StateAuthFailed) {
+				zkCli.setState(SessionAuthFailed)
+			}
+			zkCli.dispatchEvent(Event{
+				Type:  EventType(res.Type),
+				State: KeeperState(res.State),
+				Path:  zkCli.clientPath(res.Path),
+			})
+		} else if resHeader.Xid > 0 || resHeader.Xid == -4 {
+			// 普通请求或认证请求
+			if resHeader.Xid > 0 && resHeader.Zxid > 0 {
+				// 只记录普通请求的事务ID，心跳响应带的是服务端最新的事务ID，重新登记监视时会漏掉变化
+				atomic.StoreInt64(&zkCli.lastZxid, resHeader.Zxid)
+			}
 			zkCli.reqLock.Lock()
-			if req, ok := zkCli.reqMap[resHeader.xid]; ok {
-				req.resbuf = pkgBuf[16:pkgSize]
+			if req, ok := zkCli.reqMap[resHeader.Xid]; ok {
+				req.resbuf = pkgBuf[16:]
 				req.resheader = resHeader
+				if req.watcher != nil {
+					zkCli.addWatcher(req)
+				}
 				req.done <- true
-				delete(zkCli.reqMap, resHeader.xid)
+				delete(zkCli.reqMap, resHeader.Xid)
 			}
 			zkCli.reqLock.Unlock()
 		}
 	}
 }
 
+// 读出并丢弃超过大小限制的响应，以ErrPacketTooLarge结束对应的请求
+func (zkCli *ZkCli) discardResponse(pkgSize int32) error {
+	headerBuf := make([]byte, 16)
+	if _, err := io.ReadFull(zkCli.conn, headerBuf); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, zkCli.conn, int64(pkgSize)-16); err != nil {
+		return err
+	}
+	resHeader := &proto.ReplyHeader{}
+	jute.Unmarshal(headerBuf, resHeader)
+	zkCli.logError("response too large", "xid", resHeader.Xid, "size", pkgSize, "limit", zkCli.maxPacketSize)
+	zkCli.reqLock.Lock()
+	if req, ok := zkCli.reqMap[resHeader.Xid]; ok {
+		req.err = ErrPacketTooLarge
+		req.done <- true
+		delete(zkCli.reqMap, resHeader.Xid)
+	}
+	zkCli.reqLock.Unlock()
+	return nil
+}
+
+// 建立连接并完成会话认证，已有会话时带上会话ID及密码以恢复原来的会话
 func (zkCli *ZkCli) connect(serverAddr string) error {
-	// TCP拔号，超时大概在20s
-	tcpAddr, err := net.ResolveTCPAddr("tcp4", serverAddr)
-	conn, err := net.DialTCP("tcp", nil, tcpAddr)
+	// TCP拔号
+	conn, err := dialServer(serverAddr, zkCli.dialTimeout)
 	if err != nil {
-		return err // 连接超时
+		return err // 解析失败或连接超时
 	}
 	zkCli.conn = conn
+	zkCli.stateLock.Lock()
+	zkCli.server = serverAddr
+	zkCli.stateLock.Unlock()
 
-	// 连接认证
-	buf := make([]byte, 48)
-	n := encodeConnectRequest(buf, &connectRequest{
-		protocolversion: 0,
-		lastzxidseen:    0,
-		timeout:         0,
-		sessionid:       0,
-		password:        zkCli.password,
+	// 连接认证，只读服务器上建立的会话在可读写服务器上不存在，只能新建会话
+	sessionId := atomic.LoadInt64(&zkCli.sessionid)
+	if !zkCli.seenRwServer {
+		sessionId = 0
+	}
+	buf, err := encodePacket(&proto.ConnectRequest{
+		ProtocolVersion: 0,
+		LastZxidSeen:    atomic.LoadInt64(&zkCli.lastZxid),
+		TimeOut:         int32(zkCli.sessionTimeout / time.Millisecond),
+		SessionId:       sessionId,
+		Passwd:          zkCli.password,
+		ReadOnly:        zkCli.canBeReadOnly,
 	})
-	zkCli.conn.SetWriteDeadline(time.Now().Add(RecvTimeout * time.Second))
-	_, err = zkCli.conn.Write(buf[:n])
+	if err != nil {
+		zkCli.conn.Close()
+		return err
+	}
+	zkCli.conn.SetWriteDeadline(time.Now().Add(zkCli.writeTimeout))
+	_, err = zkCli.conn.Write(buf)
 	zkCli.conn.SetWriteDeadline(time.Time{})
 	if err != nil {
-		logger.Println(err)
+		zkCli.conn.Close()
 		return err
 	}
-	zkCli.conn.SetReadDeadline(time.Now().Add(RecvTimeout * time.Second))
-	_, err = zkCli.conn.Read(buf)
+	res := &proto.ConnectResponse{}
+	zkCli.conn.SetReadDeadline(time.Now().Add(zkCli.readTimeout))
+	err = zkCli.readConnectResponse(res)
 	zkCli.conn.SetReadDeadline(time.Time{})
 	if err != nil {
-		logger.Println(err)
+		zkCli.conn.Close()
 		return err
 	}
-	res := &connectResponse{}
-	decodeConnectResponse(buf[4:], res)
-	zkCli.protocolversion = res.protocolversion
-	zkCli.sessiontimeout = res.timeout
-	zkCli.sessionid = res.sessionid
-	zkCli.password = res.password
-	zkCli.state = stateConnected
-
-	// 发送请求
-	go func() {
-		err := zkCli.sentLoop()
-		if err == nil {
-			// 正常关闭连接成功
-		} else {
-			// 其它原因
+	if res.TimeOut <= 0 {
+		// 服务端拒绝了原来的会话
+		zkCli.conn.Close()
+		return ErrSessionExpired
+	}
+	zkCli.protocolversion = res.ProtocolVersion
+	zkCli.sessiontimeout = res.TimeOut
+	atomic.StoreInt64(&zkCli.sessionid, res.SessionId)
+	zkCli.password = res.Passwd
+	if res.ReadOnly {
+		atomic.StoreInt32(&zkCli.readOnly, 1)
+	} else {
+		atomic.StoreInt32(&zkCli.readOnly, 0)
+		zkCli.seenRwServer = true
+	}
+
+	// 重新发送认证信息
+	if err := zkCli.resendAuths(); err != nil {
+		zkCli.conn.Close()
+		if errors.Is(err, ErrAuthFailed) {
+			zkCli.setState(SessionAuthFailed)
 		}
-	}()
-	// 接收响应
-	go func() {
-		zkCli.recvLoop()
-		logger.Println("recvloop exit")
-	}()
+		return err
+	}
+
+	// 重新登记监视，从只读服务器切换过来时虽然是新会话，原来的监视同样需要登记
+	if err := zkCli.resendWatches(); err != nil {
+		zkCli.conn.Close()
+		return err
+	}
 
+	zkCli.logInfo("session established", "timeout", res.TimeOut, "readOnly", res.ReadOnly)
 	return nil
 }
 
-// API：连接
-func (zk *ZkCli) Connect(servers []string) error {
-	for i, serverip := range servers {
-		if !strings.ContainsRune(serverip, rune(':')) {
-			servers[i] = fmt.Sprintf("%s:%d", serverip, DefaultPort)
+// 读取连接认证的响应，响应没有响应头
+func (zkCli *ZkCli) readConnectResponse(res *proto.ConnectResponse) error {
+	pkgSizeBuf := make([]byte, 4)
+	if _, err := io.ReadFull(zkCli.conn, pkgSizeBuf); err != nil {
+		return err
+	}
+	pkgSize := BytesToInt32(pkgSizeBuf)
+	if pkgSize < 0 || pkgSize > zkCli.maxPacketSize {
+		return ErrMarshallingError
+	}
+	buf := make([]byte, pkgSize)
+	if _, err := io.ReadFull(zkCli.conn, buf); err != nil {
+		return err
+	}
+	_, err := jute.Unmarshal(buf, res)
+	if errors.Is(err, jute.ErrShortBuffer) {
+		// 3.4之前的服务端不返回最后的readOnly字段
+		_, err = jute.Unmarshal(append(buf, 0), res)
+	}
+	return err
+}
+
+// 从下一个服务器开始轮流尝试，直到连上其中一个，所有服务器都试过一遍后返回
+func (zkCli *ZkCli) reconnect() error {
+	zkCli.stateLock.Lock()
+	rwServer := zkCli.rwServer
+	zkCli.rwServer = ""
+	zkCli.stateLock.Unlock()
+	if rwServer != "" {
+		// 后台找到了可读写的服务器
+		err := zkCli.connect(rwServer)
+		if err == nil || err == ErrSessionExpired {
+			return err
 		}
+		zkCli.logWarn("connect failed", "addr", rwServer, "err", err)
 	}
-	zk.state = stateConnecting
-	for i := range servers {
-		err := zk.connect(servers[i])
+	for i := 0; i < zkCli.hostProvider.Len(); i++ {
+		serverAddr := zkCli.hostProvider.Next()
+		err := zkCli.connect(serverAddr)
 		if err == nil {
-			zk.state = stateConnected
 			return nil
 		}
-		//return err
+		if err == ErrSessionExpired {
+			return err
+		}
+		zkCli.logWarn("connect failed", "addr", serverAddr, "err", err)
 	}
-	zk.state = stateDisconnect
 	return errMap[errConnectionDisabled]
 }
+
+// 连接守护：收发循环退出后重连，直到会话过期或主动关闭
+func (zkCli *ZkCli) loop() {
+	for {
+		stop := make(chan bool)
+		errchan := make(chan error, 2)
+		// 发送请求
+		go func() {
+			errchan <- zkCli.sentLoop(stop)
+		}()
+		// 接收响应
+		go func() {
+			errchan <- zkCli.recvLoop()
+		}()
+		if atomic.LoadInt32(&zkCli.readOnly) == 1 {
+			go zkCli.searchRwServer(zkCli.conn, stop)
+		}
+		err := <-errchan
+		close(stop)
+		zkCli.conn.Close()
+		<-errchan
+		if atomic.LoadInt32(&zkCli.closed) == 1 {
+			// 正常关闭连接
+			zkCli.shutdown(ErrClosed)
+			zkCli.setState(SessionClosed)
+			return
+		}
+		zkCli.logWarn("connection lost", "err", err)
+		// 已发出的请求收不到响应了
+		zkCli.flushRequests(ErrConnectionClosed)
+
+		zkCli.setState(SessionSuspended)
+		sessionId := atomic.LoadInt64(&zkCli.sessionid)
+		for round := 1; ; round++ {
+			err = zkCli.reconnect()
+			if err == nil {
+				if atomic.LoadInt64(&zkCli.sessionid) == sessionId {
+					zkCli.setState(SessionReconnected)
+				} else {
+					// 只读服务器上的会话无法恢复，建立的是新会话
+					zkCli.setState(zkCli.connectedState())
+				}
+				break
+			}
+			if err == ErrSessionExpired {
+				zkCli.setState(SessionExpired)
+				zkCli.logError("session expired")
+				zkCli.shutdown(ErrSessionExpired)
+				return
+			}
+			// 这一轮没有连上，重连期间发出的请求不再等待，避免调用者一直阻塞
+			zkCli.flushRequests(ErrConnectionClosed)
+			select {
+			case <-time.After(reconnectBackoff(round)):
+			case <-zkCli.closechan:
+			}
+			if atomic.LoadInt32(&zkCli.closed) == 1 {
+				zkCli.shutdown(ErrClosed)
+				zkCli.setState(SessionClosed)
+				return
+			}
+		}
+	}
+}
+
+// API：连接，servers中也可以使用连接串，如[]string{"zk1:2181,zk2:2181/app/prod"}，
+// 末尾的路径为chroot，之后所有操作的路径都相对于它，返回的路径也会去掉它
+func (zk *ZkCli) Connect(servers []string) error {
+	servers, chroot, err := parseConnectString(servers)
+	if err != nil {
+		return err
+	}
+	zk.chroot = chroot
+	for i, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			// 没有端口号，包括不带方括号的IPv6地址
+			servers[i] = net.JoinHostPort(strings.Trim(server, "[]"), strconv.Itoa(DefaultPort))
+		}
+	}
+	zk.servers = servers
+	if err := zk.hostProvider.Init(servers); err != nil {
+		return err
+	}
+	zk.setState(SessionConnecting)
+	if err := zk.reconnect(); err != nil {
+		zk.setState(SessionDisconnected)
+		return err
+	}
+	zk.setState(zk.connectedState())
+	go zk.loop()
+	return nil
+}
@@ -0,0 +1,231 @@
+package zk
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReconnect(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	id := cli.SessionID()
+
+	if _, err := cli.Create("/eph", nil, CreateEphemeral, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 连接断开后恢复原来的会话，临时节点仍然存在
+	srv.Disconnect(id)
+	wait(t, SessionSuspended)
+	wait(t, SessionReconnected)
+	if cli.SessionID() != id || cli.State() != SessionConnected {
+		t.Fatalf("after reconnect: session 0x%x, state %v", cli.SessionID(), cli.State())
+	}
+	if ok, stat, err := cli.Exists("/eph"); !ok || err != nil || stat.EphemeralOwner != id {
+		t.Fatalf("ephemeral node after reconnect: %v, %+v, %v", ok, stat, err)
+	}
+
+	// 服务端重启期间一直重连，重启后恢复会话
+	srv.Stop()
+	wait(t, SessionSuspended)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, SessionReconnected)
+	if cli.SessionID() != id {
+		t.Fatalf("new session 0x%x after restart, want 0x%x", cli.SessionID(), id)
+	}
+	if _, err := cli.Create("/eph2", nil, CreateEphemeral, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	other := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/eph", nil, CreateEphemeral, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 服务端拒绝恢复会话，不再重连
+	srv.ExpireSession(cli.SessionID())
+	wait(t, SessionExpired)
+	if _, _, err := cli.Get("/"); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Get after expiry: got %v, want ErrSessionExpired", err)
+	}
+	if ok, _, _ := other.Exists("/eph"); ok {
+		t.Fatal("ephemeral node survived session expiry")
+	}
+}
+
+// 没有连接守护运行时，请求直接返回而不是一直阻塞
+func TestRequestWithoutConnection(t *testing.T) {
+	cli := New()
+	mustReturn(t, "Get before Connect", func() {
+		if _, _, err := cli.Get("/"); !errors.Is(err, ErrConnectionClosed) {
+			t.Errorf("Get before Connect: got %v, want ErrConnectionClosed", err)
+		}
+	})
+
+	// 找一个没有监听的端口
+	srv := newServer(t)
+	addr := srv.Addr()
+	srv.Close()
+	cli = New(WithDialTimeout(time.Second))
+	if err := cli.Connect([]string{addr}); err == nil {
+		t.Fatal("Connect to a closed port succeeded")
+	}
+	if state := cli.State(); state != SessionDisconnected {
+		t.Fatalf("state after a failed Connect: %v", state)
+	}
+	mustReturn(t, "Get after a failed Connect", func() {
+		if _, _, err := cli.Get("/"); !errors.Is(err, ErrConnectionClosed) {
+			t.Errorf("Get after a failed Connect: got %v, want ErrConnectionClosed", err)
+		}
+	})
+	mustReturn(t, "AddAuth after a failed Connect", func() {
+		if err := cli.AddAuth("digest", []byte("user:secret")); !errors.Is(err, ErrConnectionClosed) {
+			t.Errorf("AddAuth after a failed Connect: got %v, want ErrConnectionClosed", err)
+		}
+	})
+}
+
+// 服务端不可用时，重连期间发出的请求在这一轮重连失败后返回
+func TestRequestWhileServerDown(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	srv.Stop()
+	wait(t, SessionSuspended)
+
+	mustReturn(t, "Get while reconnecting", func() {
+		if _, _, err := cli.Get("/"); !errors.Is(err, ErrConnectionClosed) {
+			t.Errorf("Get while reconnecting: got %v, want ErrConnectionClosed", err)
+		}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, SessionReconnected)
+	if _, _, err := cli.Get("/"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := New(opt)
+	if err := cli.Connect([]string{srv.Addr()}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, ch, err := cli.ExistsW("/never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, SessionClosed)
+	if _, _, err := cli.Get("/"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after Close: got %v, want ErrClosed", err)
+	}
+	if err := cli.Close(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("second Close: got %v, want ErrClosed", err)
+	}
+	// 会话已在服务端结束
+	if ids := srv.Sessions(); len(ids) != 0 {
+		t.Fatalf("sessions after Close: %v", ids)
+	}
+	if ev := waitEvent(t, ch); ev.Type != EventNone || ev.State != StateClosed {
+		t.Fatalf("watch event after Close: %+v", ev)
+	}
+}
+
+// 重连期间关闭不等待这一轮重连结束
+func TestCloseWhileSuspended(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := New(opt)
+	if err := cli.Connect([]string{srv.Addr()}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, ch, err := cli.ExistsW("/never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Stop()
+	wait(t, SessionSuspended)
+	// 等第一轮重连失败，此时正在等待下一轮
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	if err := cli.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Fatalf("Close took %v while suspended", d)
+	}
+	wait(t, SessionClosed)
+	if _, _, err := cli.Get("/"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Get after Close: got %v, want ErrClosed", err)
+	}
+	if ev := waitEvent(t, ch); ev.Type != EventNone || ev.State != StateClosed {
+		t.Fatalf("watch event after Close: %+v", ev)
+	}
+}
+
+func TestPacketSize(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	// 请求按实际大小编码，不再受2KB的限制
+	big := bytes.Repeat([]byte("x"), 100*1024)
+	if _, err := cli.Create("/big", big, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := cli.Get("/big"); err != nil || !bytes.Equal(data, big) {
+		t.Fatalf("Get: %d bytes, %v", len(data), err)
+	}
+
+	var suspended int32
+	small := newClient(t, srv.Addr(), WithMaxPacketSize(4096), WithStateListener(func(ev SessionEvent) {
+		if ev.State == SessionSuspended {
+			atomic.AddInt32(&suspended, 1)
+		}
+	}))
+	if _, err := small.Create("/3k", make([]byte, 3000), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := small.Create("/5k", make([]byte, 5000), CreatePersistent, nil); !errors.Is(err, ErrPacketTooLarge) {
+		t.Fatalf("Create over the limit: got %v, want ErrPacketTooLarge", err)
+	}
+	if ok, _, _ := cli.Exists("/5k"); ok {
+		t.Fatal("request over the limit was sent")
+	}
+
+	// 数据不超过限制时，带上响应头及节点状态的响应同样可以接收
+	if _, err := cli.Create("/4k", make([]byte, 4096), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := small.Get("/4k"); err != nil || len(data) != 4096 {
+		t.Fatalf("Get at the limit: %d bytes, %v", len(data), err)
+	}
+
+	// 响应超过限制时只有这个请求失败，连接保持可用
+	if _, _, err := small.Get("/big"); !errors.Is(err, ErrPacketTooLarge) {
+		t.Fatalf("Get over the limit: got %v, want ErrPacketTooLarge", err)
+	}
+	if data, _, err := small.Get("/3k"); err != nil || len(data) != 3000 {
+		t.Fatalf("Get after a large response: %d bytes, %v", len(data), err)
+	}
+	if n := atomic.LoadInt32(&suspended); n != 0 || small.State() != SessionConnected {
+		t.Fatalf("connection lost after a large response: %d suspensions, state %v", n, small.State())
+	}
+}
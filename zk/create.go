@@ -1,98 +1,133 @@
 package zk
 
-import ()
+import (
+	"context"
+	"time"
 
-type createRequest struct {
-	xid    int32
-	opcode int32
-	path   string
-	data   []byte
-	acl    []ACL
-	flags  int32
-}
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+const (
+	CreatePersistent                  = 0 // 持久节点
+	CreateEphemeral                   = 1 // 临时节点，会话结束后自动删除
+	CreateSequential                  = 2 // 顺序节点，服务端在路径后追加递增序号
+	CreateEphemeralSequential         = 3 // 临时顺序节点
+	CreateContainer                   = 4 // 容器节点，最后一个子节点删除后由服务端回收
+	CreatePersistentWithTTL           = 5 // TTL节点，超过TTL没有修改且没有子节点时由服务端删除
+	CreatePersistentSequentialWithTTL = 6 // TTL顺序节点
+)
 
-func encodeCreateRequest(buf []byte, req *createRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	data_len := int32(len(req.data))
-	acl_cnt := len(req.acl)
-	n := 4
-	Int32ToBytes(buf[n:], req.xid)
-	n += 4
-	Int32ToBytes(buf[n:], req.opcode)
-	n += 4
-	Int32ToBytes(buf[n:], path_len)
-	n += 4
-	copy(buf[n:], path)
-	n += int(path_len)
-	Int32ToBytes(buf[n:], data_len)
-	n += 4
-	copy(buf[n:], req.data)
-	n += int(data_len)
-	Int32ToBytes(buf[n:], int32(acl_cnt))
-	n += 4
-	for i := 0; i < acl_cnt; i++ {
-		Int32ToBytes(buf[n:], req.acl[i].Perms)
-		n += 4
-		acl_scheme := []byte(req.acl[i].Scheme)
-		acl_scheme_len := len(acl_scheme)
-		Int32ToBytes(buf[n:], int32(acl_scheme_len))
-		n += 4
-		copy(buf[n:], acl_scheme)
-		n += acl_scheme_len
-		acl_id := []byte(req.acl[i].Id)
-		acl_id_len := len(acl_id)
-		Int32ToBytes(buf[n:], int32(acl_id_len))
-		n += 4
-		copy(buf[n:], acl_id)
-		n += acl_id_len
+// 生成新建节点的请求体，返回对应的操作码，容器节点及TTL节点需要使用单独的操作码，ttl只对TTL节点有效
+func newCreateRequest(path string, data []byte, mode int32, acl []ACL, ttl time.Duration) (int32, interface{}) {
+	if acl == nil {
+		acl = WorldACL // 默认
+	}
+	switch mode {
+	case CreateContainer:
+		return opCreateContainer, &proto.CreateRequest{Path: path, Data: data, Acl: toProtoACL(acl), Flags: mode}
+	case CreatePersistentWithTTL, CreatePersistentSequentialWithTTL:
+		return opCreateTTL, &proto.CreateTTLRequest{Path: path, Data: data, Acl: toProtoACL(acl), Flags: mode, Ttl: int64(ttl / time.Millisecond)}
 	}
-	Int32ToBytes(buf[n:], req.flags)
-	Int32ToBytes(buf[0:], int32(n))
-	return int32(n + 4)
+	return opCreate, &proto.CreateRequest{Path: path, Data: data, Acl: toProtoACL(acl), Flags: mode}
 }
 
-type createResponse struct {
-	// 这里还有节点的路径，但目前不关心
+// TTL节点必须通过CreateTTL指定TTL，否则服务端会收到为0的TTL
+func isTTLMode(mode int32) bool {
+	return mode == CreatePersistentWithTTL || mode == CreatePersistentSequentialWithTTL
 }
 
-func decodeCreateResponse(buf []byte, res *createResponse) {
+func (zkCli *ZkCli) create(ctx context.Context, path string, data []byte, mode int32, acl []ACL) (string, error) {
+	if isTTLMode(mode) {
+		return "", ErrBadArguments
+	}
+	opcode, pkt := newCreateRequest(zkCli.serverPath(path), data, mode, acl, 0)
+	req, err := zkCli.newRequest(opcode, pkt, nil)
+	if err != nil {
+		return "", err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return "", codeToError(req.resheader.Err)
+		}
+		res := &proto.CreateResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return "", err
+		}
+		return zkCli.clientPath(res.Path), nil
+	}
+	return "", req.err
 }
 
-func (zkCli *ZkCli) create(path string, data []byte) error {
-	buf := make([]byte, BufferSize)
-	n := encodeCreateRequest(buf, &createRequest{
-		xid:    zkCli.getNextXid(),
-		opcode: opCreate,
-		path:   path,
-		data:   data,
-		acl:    WorldACL, // 默认
-		flags:  0,        // 默认
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opCreate,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
+// 新建节点并返回节点状态，普通节点使用opCreate2，容器节点及TTL节点的响应本身就带有节点状态
+func (zkCli *ZkCli) create2(ctx context.Context, path string, data []byte, mode int32, acl []ACL, ttl time.Duration) (string, *Stat, error) {
+	if isTTLMode(mode) && ttl <= 0 {
+		return "", nil, ErrBadArguments
+	}
+	opcode, pkt := newCreateRequest(zkCli.serverPath(path), data, mode, acl, ttl)
+	if opcode == opCreate {
+		opcode = opCreate2
+	}
+	req, err := zkCli.newRequest(opcode, pkt, nil)
+	if err != nil {
+		return "", nil, err
 	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
+	zkCli.sendRequest(ctx, req)
 	if req.err == nil {
-		//res := &createResponse{}
-		//decodeCreateResponse(req.res, res)
-		//logger.Println(req.resbuf)
-		return nil
+		if req.resheader.Err != errOk {
+			return "", nil, codeToError(req.resheader.Err)
+		}
+		res := &proto.Create2Response{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return "", nil, err
+		}
+		stat := Stat(res.Stat)
+		return zkCli.clientPath(res.Path), &stat, nil
 	}
-	// 这里应该判断各种错误
-	return req.err
+	return "", nil, req.err
+}
+
+// API：新建节点，mode为Create*之一，TTL节点请使用CreateTTL，acl为空时使用WorldACL，返回服务端实际创建的路径
+func (zk *ZkCli) Create(path string, data []byte, mode int32, acl []ACL) (string, error) {
+	return zk.create(context.Background(), path, data, mode, acl)
+}
+
+// API：新建节点，ctx结束时放弃等待并返回ctx.Err()，此时节点可能已被创建
+func (zk *ZkCli) CreateContext(ctx context.Context, path string, data []byte, mode int32, acl []ACL) (string, error) {
+	return zk.create(ctx, path, data, mode, acl)
+}
+
+// API：新建节点，同时返回新节点的状态，TTL节点请使用CreateTTL，需要服务端3.5以上
+func (zk *ZkCli) Create2(path string, data []byte, mode int32, acl []ACL) (string, *Stat, error) {
+	return zk.create2(context.Background(), path, data, mode, acl, 0)
+}
+
+// API：新建节点并返回节点状态，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) Create2Context(ctx context.Context, path string, data []byte, mode int32, acl []ACL) (string, *Stat, error) {
+	return zk.create2(ctx, path, data, mode, acl, 0)
+}
+
+// API：新建容器节点，最后一个子节点被删除后由服务端回收，适合作为锁、选举等临时子节点的父节点
+func (zk *ZkCli) CreateContainer(path string, data []byte, acl []ACL) (string, *Stat, error) {
+	return zk.create2(context.Background(), path, data, CreateContainer, acl, 0)
+}
+
+// API：新建容器节点，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) CreateContainerContext(ctx context.Context, path string, data []byte, acl []ACL) (string, *Stat, error) {
+	return zk.create2(ctx, path, data, CreateContainer, acl, 0)
 }
 
-// API：新建节点
-func (zk *ZkCli) Create(path string, data []byte) error {
-	return zk.create(path, data)
+// API：新建TTL节点，mode为CreatePersistentWithTTL或CreatePersistentSequentialWithTTL，
+// 节点超过ttl没有修改且没有子节点时由服务端删除，需要服务端开启extendedTypesEnabled
+func (zk *ZkCli) CreateTTL(path string, data []byte, mode int32, acl []ACL, ttl time.Duration) (string, *Stat, error) {
+	return zk.CreateTTLContext(context.Background(), path, data, mode, acl, ttl)
+}
+
+// API：新建TTL节点，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) CreateTTLContext(ctx context.Context, path string, data []byte, mode int32, acl []ACL, ttl time.Duration) (string, *Stat, error) {
+	if !isTTLMode(mode) || ttl <= 0 {
+		return "", nil, ErrBadArguments
+	}
+	return zk.create2(ctx, path, data, mode, acl, ttl)
 }
@@ -0,0 +1,117 @@
+package zk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSequential(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/seq", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 序号为父节点的子节点版本号，补齐到10位
+	for _, c := range []struct {
+		prefix string
+		mode   int32
+		want   string
+	}{
+		{"/seq/n-", CreateEphemeralSequential, "/seq/n-0000000000"},
+		{"/seq/n-", CreateEphemeralSequential, "/seq/n-0000000001"},
+		{"/seq/lock-", CreateEphemeralSequential, "/seq/lock-0000000002"},
+		{"/seq/p-", CreateSequential, "/seq/p-0000000003"},
+	} {
+		path, err := cli.Create(c.prefix, nil, c.mode, nil)
+		if err != nil || path != c.want {
+			t.Fatalf("Create(%s): got %q, %v, want %q", c.prefix, path, err, c.want)
+		}
+	}
+}
+
+func TestEphemeral(t *testing.T) {
+	srv := newServer(t)
+	owner := New()
+	if err := owner.Connect([]string{srv.Addr()}); err != nil {
+		t.Fatal(err)
+	}
+	cli := newClient(t, srv.Addr())
+
+	if _, err := owner.Create("/eph", nil, CreateEphemeral, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.Create("/eph/child", nil, CreatePersistent, nil); !errors.Is(err, ErrNoChildrenForEphemerals) {
+		t.Fatalf("child of ephemeral: got %v, want ErrNoChildrenForEphemerals", err)
+	}
+	_, stat, err := cli.Get("/eph")
+	if err != nil || stat.EphemeralOwner != owner.SessionID() {
+		t.Fatalf("Get: %+v, %v", stat, err)
+	}
+	_, _, ch, err := cli.ExistsW("/eph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 会话结束后临时节点被删除
+	if err := owner.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, ch); ev.Type != EventNodeDeleted || ev.Path != "/eph" {
+		t.Fatalf("event: %+v", ev)
+	}
+	if ok, _, _ := cli.Exists("/eph"); ok {
+		t.Fatal("ephemeral node survived its session")
+	}
+}
+
+func TestCreateContainerMode(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	path, err := cli.Create("/locks", nil, CreateContainer, nil)
+	if err != nil || path != "/locks" {
+		t.Fatalf("Create container: %q, %v", path, err)
+	}
+	if _, err := cli.Create("/locks/l-", nil, CreateEphemeralSequential, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateVariants(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	path, stat, err := cli.Create2("/a", []byte("abc"), CreatePersistent, nil)
+	if err != nil || path != "/a" || stat.DataLength != 3 || stat.Version != 0 {
+		t.Fatalf("Create2: %q, %+v, %v", path, stat, err)
+	}
+	path, stat, err = cli.CreateContainer("/c", nil, nil)
+	if err != nil || path != "/c" || stat.Czxid == 0 {
+		t.Fatalf("CreateContainer: %q, %+v, %v", path, stat, err)
+	}
+	path, stat, err = cli.CreateTTL("/ttl-", nil, CreatePersistentSequentialWithTTL, nil, time.Minute)
+	if err != nil || path != "/ttl-0000000002" || stat.Czxid == 0 {
+		t.Fatalf("CreateTTL: %q, %+v, %v", path, stat, err)
+	}
+
+	// TTL节点只能通过CreateTTL创建，且必须给出TTL
+	if _, err := cli.Create("/t", nil, CreatePersistentWithTTL, nil); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("Create with a TTL mode: got %v, want ErrBadArguments", err)
+	}
+	if _, _, err := cli.Create2("/t", nil, CreatePersistentWithTTL, nil); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("Create2 with a TTL mode: got %v, want ErrBadArguments", err)
+	}
+	if _, _, err := cli.CreateTTL("/t", nil, CreatePersistent, nil, time.Minute); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("CreateTTL with a non-TTL mode: got %v, want ErrBadArguments", err)
+	}
+	if _, _, err := cli.CreateTTL("/t", nil, CreatePersistentWithTTL, nil, 0); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("CreateTTL without a TTL: got %v, want ErrBadArguments", err)
+	}
+	if _, err := cli.Multi().Create("/t", nil, CreatePersistentWithTTL, nil).Commit(); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("Txn.Create with a TTL mode: got %v, want ErrBadArguments", err)
+	}
+	if ok, _, _ := cli.Exists("/t"); ok {
+		t.Fatal("node created by a rejected request")
+	}
+}
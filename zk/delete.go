@@ -1,74 +1,50 @@
 package zk
 
-type deleteRequest struct {
-	xid     int32
-	opcode  int32
-	path    string
-	version int32
-}
-
-func encodeDeleteRequest(buf []byte, req *deleteRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	n := 4
-	Int32ToBytes(buf[n:], req.xid)
-	n += 4
-	Int32ToBytes(buf[n:], req.opcode)
-	n += 4
-	Int32ToBytes(buf[n:], path_len)
-	n += 4
-	copy(buf[n:], path)
-	n += int(path_len)
-	Int32ToBytes(buf[n:], req.version)
-	Int32ToBytes(buf[0:], int32(n))
-	return int32(n + 4)
-}
-
-type deleteResponse struct {
-}
+import (
+	"context"
+	"errors"
 
-func decodeDeleteResponse(buf []byte, res *deleteResponse) {
-}
+	"github.com/xianmau/gozk/proto"
+)
 
-func (zkCli *ZkCli) delete(path string) error {
-	buf := make([]byte, BufferSize)
-	n := encodeDeleteRequest(buf, &deleteRequest{
-		xid:     zkCli.getNextXid(),
-		opcode:  opDelete,
-		path:    path,
-		version: -1, // 默认
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opDelete,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
+func (zkCli *ZkCli) delete(ctx context.Context, path string, version int32) error {
+	req, err := zkCli.newRequest(opDelete, &proto.DeleteRequest{Path: zkCli.serverPath(path), Version: version}, nil)
+	if err != nil {
+		return err
 	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
+	zkCli.sendRequest(ctx, req)
 	if req.err == nil {
-		//res := &deleteResponse{}
-		//decodeDeleteResponse(req.res, res)
-		logger.Println(req.resbuf)
+		if req.resheader.Err != errOk {
+			return codeToError(req.resheader.Err)
+		}
 		return nil
 	}
-	// 这里应该判断各种错误
 	return req.err
 }
 
 // API：删除节点
 func (zk *ZkCli) Delete(path string) error {
-	return zk.delete(path)
+	return zk.delete(context.Background(), path, -1)
+}
+
+// API：删除节点，ctx结束时放弃等待并返回ctx.Err()，此时节点可能已被删除
+func (zk *ZkCli) DeleteContext(ctx context.Context, path string) error {
+	return zk.delete(ctx, path, -1)
+}
+
+// API：删除指定版本的节点，版本号不匹配时返回ErrBadVersion
+func (zk *ZkCli) DeleteVersion(path string, version int32) error {
+	return zk.delete(context.Background(), path, version)
+}
+
+// API：删除指定版本的节点，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) DeleteVersionContext(ctx context.Context, path string, version int32) error {
+	return zk.delete(ctx, path, version)
 }
 
 // API：递归删除节点
 func (zk *ZkCli) DeleteRecur(path string) error {
-	if flag, err := zk.Exists(path); err == nil && !flag {
+	if flag, _, err := zk.Exists(path); err != nil || !flag {
 		return err
 	}
 	children, err := zk.Children(path)
@@ -77,7 +53,12 @@ func (zk *ZkCli) DeleteRecur(path string) error {
 	}
 	for _, znode := range children {
 		sub_znode := path + "/" + znode
-		zk.DeleteRecur(sub_znode)
+		if err := zk.DeleteRecur(sub_znode); err != nil && !errors.Is(err, ErrNoNode) {
+			return err
+		}
+	}
+	if err := zk.delete(context.Background(), path, -1); err != nil && !errors.Is(err, ErrNoNode) {
+		return err
 	}
-	return zk.delete(path)
+	return nil
 }
@@ -1,63 +1,59 @@
 package zk
 
-type existRequest struct {
-	xid    int32
-	opcode int32
-	path   string
-	watch  bool
-}
-
-func encodeExistRequest(buf []byte, req *existRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[12:], path_len)
-	copy(buf[16:], path)
-	BoolToBytes(buf[16+path_len:], req.watch)
-	Int32ToBytes(buf[0:], 13+path_len)
-	return 17 + path_len
-}
+import (
+	"context"
 
-type existResponse struct {
-	// 这里还有节点的状态，但目前不关心
-}
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
 
-func decodeExistResponse(buf []byte, res *existResponse) {
-}
-
-func (zkCli *ZkCli) exists(path string) (bool, error) {
-	buf := make([]byte, BufferSize)
-	n := encodeExistRequest(buf, &existRequest{
-		xid:    zkCli.getNextXid(),
-		opcode: opExists,
-		path:   path,
-		watch:  false,
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opExists,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
+func (zkCli *ZkCli) exists(ctx context.Context, path string, watch bool) (bool, *Stat, <-chan Event, error) {
+	var w *watcher
+	if watch {
+		w = newWatcher(path, watchTypeData)
+	}
+	req, err := zkCli.newRequest(opExists, &proto.ExistsRequest{Path: zkCli.serverPath(path), Watch: watch}, w)
+	if err != nil {
+		return false, nil, nil, err
 	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
+	zkCli.sendRequest(ctx, req)
 	if req.err == nil {
-		if req.resheader.errcode == 0 {
-			return true, nil
-		} else if req.resheader.errcode == 101 {
-			return false, nil
+		var ch <-chan Event
+		if w != nil {
+			ch = w.ch
 		}
+		if req.resheader.Err == errOk {
+			res := &proto.ExistsResponse{}
+			if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+				return false, nil, nil, err
+			}
+			stat := Stat(res.Stat)
+			return true, &stat, ch, nil
+		} else if req.resheader.Err == errNoNode {
+			return false, nil, ch, nil
+		}
+		return false, nil, nil, codeToError(req.resheader.Err)
 	}
-	return false, req.err
+	return false, nil, nil, req.err
 }
 
 // API：测试节点是否存在
-func (zk *ZkCli) Exists(path string) (bool, error) {
-	return zk.exists(path)
+func (zk *ZkCli) Exists(path string) (bool, *Stat, error) {
+	return zk.ExistsContext(context.Background(), path)
+}
+
+// API：测试节点是否存在，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) ExistsContext(ctx context.Context, path string) (bool, *Stat, error) {
+	flag, stat, _, err := zk.exists(ctx, path, false)
+	return flag, stat, err
+}
+
+// API：测试节点是否存在，并监视节点的创建、删除及数据变化
+func (zk *ZkCli) ExistsW(path string) (bool, *Stat, <-chan Event, error) {
+	return zk.exists(context.Background(), path, true)
+}
+
+// API：测试节点是否存在并监视节点，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) ExistsWContext(ctx context.Context, path string) (bool, *Stat, <-chan Event, error) {
+	return zk.exists(ctx, path, true)
 }
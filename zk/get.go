@@ -1,66 +1,56 @@
 package zk
 
-type getRequest struct {
-	xid    int32
-	opcode int32
-	path   string
-	watch  bool
-}
+import (
+	"context"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
 
-func encodeGetRequest(buf []byte, req *getRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[12:], path_len)
-	copy(buf[16:], path)
-	BoolToBytes(buf[16+path_len:], req.watch)
-	Int32ToBytes(buf[0:], 13+path_len)
-	return 17 + path_len
+func (zkCli *ZkCli) get(ctx context.Context, path string, watch bool) ([]byte, *Stat, <-chan Event, error) {
+	var w *watcher
+	if watch {
+		w = newWatcher(path, watchTypeData)
+	}
+	req, err := zkCli.newRequest(opGet, &proto.GetDataRequest{Path: zkCli.serverPath(path), Watch: watch}, w)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return nil, nil, nil, codeToError(req.resheader.Err)
+		}
+		res := &proto.GetDataResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return nil, nil, nil, err
+		}
+		stat := Stat(res.Stat)
+		if w != nil {
+			return res.Data, &stat, w.ch, nil
+		}
+		return res.Data, &stat, nil, nil
+	}
+	return nil, nil, nil, req.err
 }
 
-type getResponse struct {
-	data []byte
-	// 这里还有节点的状态，但目前不关心
+// API：获取节点数据
+func (zk *ZkCli) Get(path string) ([]byte, *Stat, error) {
+	return zk.GetContext(context.Background(), path)
 }
 
-func decodeGetResponse(buf []byte, res *getResponse) {
-	data_len := BytesToInt32(buf)
-	res.data = buf[4 : 4+data_len]
+// API：获取节点数据，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) GetContext(ctx context.Context, path string) ([]byte, *Stat, error) {
+	data, stat, _, err := zk.get(ctx, path, false)
+	return data, stat, err
 }
 
-func (zkCli *ZkCli) get(path string) ([]byte, error) {
-	buf := make([]byte, BufferSize)
-	n := encodeGetRequest(buf, &getRequest{
-		xid:    zkCli.getNextXid(),
-		opcode: opGet,
-		path:   path,
-		watch:  false,
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opGet,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
-	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
-	if req.err == nil {
-		res := &getResponse{}
-		decodeGetResponse(req.resbuf, res)
-		//logger.Println(req.resbuf)
-		return res.data, nil
-	}
-	// 这里应该判断各种错误
-	return nil, req.err
+// API：获取节点数据，并监视节点的变化
+func (zk *ZkCli) GetW(path string) ([]byte, *Stat, <-chan Event, error) {
+	return zk.get(context.Background(), path, true)
 }
 
-// API：获取节点数据
-func (zk *ZkCli) Get(path string) ([]byte, error) {
-	return zk.get(path)
+// API：获取节点数据并监视节点，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) GetWContext(ctx context.Context, path string) ([]byte, *Stat, <-chan Event, error) {
+	return zk.get(ctx, path, true)
 }
@@ -0,0 +1,38 @@
+package zk
+
+import (
+	"context"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+func (zkCli *ZkCli) getAcl(ctx context.Context, path string) ([]ACL, *Stat, error) {
+	req, err := zkCli.newRequest(opGetAcl, &proto.GetACLRequest{Path: zkCli.serverPath(path)}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return nil, nil, codeToError(req.resheader.Err)
+		}
+		res := &proto.GetACLResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return nil, nil, err
+		}
+		stat := Stat(res.Stat)
+		return fromProtoACL(res.Acl), &stat, nil
+	}
+	return nil, nil, req.err
+}
+
+// API：获取节点的ACL
+func (zk *ZkCli) GetACL(path string) ([]ACL, *Stat, error) {
+	return zk.getAcl(context.Background(), path)
+}
+
+// API：获取节点的ACL，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) GetACLContext(ctx context.Context, path string) ([]ACL, *Stat, error) {
+	return zk.getAcl(ctx, path)
+}
@@ -1,27 +1,29 @@
 package zk
 
-import ()
+import (
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
 
-type requestHeader struct {
-	xid    int32
-	opcode int32
+// 编码请求包：4字节的长度 + 依次编码的各记录
+func encodePacket(records ...interface{}) ([]byte, error) {
+	e := jute.NewEncoder()
+	e.WriteInt(0) // 长度，编码完成后填写
+	for _, r := range records {
+		if err := e.Encode(r); err != nil {
+			return nil, err
+		}
+	}
+	buf := e.Bytes()
+	Int32ToBytes(buf, int32(len(buf)-4))
+	return buf, nil
 }
 
-func encodeRequestHeader(buf []byte, req *requestHeader) int32 {
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[0:], 8)
-	return 12
-}
-
-type responseHeader struct {
-	xid     int32
-	zxid    int64
-	errcode int32
-}
-
-func decodeResponseHeader(buf []byte, res *responseHeader) {
-	res.xid = BytesToInt32(buf)
-	res.zxid = BytesToInt64(buf[4:])
-	res.errcode = BytesToInt32(buf[12:])
+// 编码请求头及请求体，body为空时只有请求头
+func encodeRequest(xid int32, opcode int32, body interface{}) ([]byte, error) {
+	header := &proto.RequestHeader{Xid: xid, Type: opcode}
+	if body == nil {
+		return encodePacket(header)
+	}
+	return encodePacket(header, body)
 }
@@ -0,0 +1,95 @@
+package zk
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// 服务器地址提供者，决定连接及重连时依次尝试哪些服务器，可通过WithHostProvider替换
+type HostProvider interface {
+	// Connect时调用，servers为host:port形式，host可以是域名
+	Init(servers []string) error
+	// 服务器数量，每一轮重连最多尝试这么多次
+	Len() int
+	// 下一个要尝试的服务器，连接失败后会再次调用以轮换到其他服务器
+	Next() string
+}
+
+// 默认的地址提供者：打乱顺序后轮流返回，避免所有客户端都连到第一个服务器上
+type shuffleHostProvider struct {
+	mu      sync.Mutex
+	servers []string
+	next    int
+}
+
+func (hp *shuffleHostProvider) Init(servers []string) error {
+	if len(servers) == 0 {
+		return ErrBadArguments
+	}
+	shuffled := make([]string, len(servers))
+	copy(shuffled, servers)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	hp.mu.Lock()
+	hp.servers = shuffled
+	hp.next = 0
+	hp.mu.Unlock()
+	return nil
+}
+
+func (hp *shuffleHostProvider) Len() int {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	return len(hp.servers)
+}
+
+func (hp *shuffleHostProvider) Next() string {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	server := hp.servers[hp.next]
+	hp.next = (hp.next + 1) % len(hp.servers)
+	return server
+}
+
+// 每次拨号都重新解析域名，依次尝试解析出的每个地址（包括IPv6），域名变更后无需重启客户端
+func dialServer(server string, timeout time.Duration) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	// 同一域名下的多个地址也打乱顺序
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = net.DialTimeout("tcp", net.JoinHostPort(addr, port), timeout)
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// 第n轮（从1开始）所有服务器都连不上之后的等待时间，指数增长并加上随机抖动
+func reconnectBackoff(n int) time.Duration {
+	backoff := ReconnectInterval * time.Millisecond
+	for i := 1; i < n && backoff < MaxReconnectInterval*time.Millisecond; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxReconnectInterval*time.Millisecond {
+		backoff = MaxReconnectInterval * time.Millisecond
+	}
+	// 在[backoff/2, backoff)之间随机，避免大量客户端同时重连
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
@@ -0,0 +1,111 @@
+package zk
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	for n := 1; n <= 10; n++ {
+		base := ReconnectInterval * time.Millisecond << uint(n-1)
+		if base > MaxReconnectInterval*time.Millisecond {
+			base = MaxReconnectInterval * time.Millisecond
+		}
+		for i := 0; i < 100; i++ {
+			if d := reconnectBackoff(n); d < base/2 || d >= base {
+				t.Fatalf("round %d: backoff %v not in [%v, %v)", n, d, base/2, base)
+			}
+		}
+	}
+}
+
+func TestShuffleHostProvider(t *testing.T) {
+	hp := &shuffleHostProvider{}
+	if err := hp.Init(nil); !errors.Is(err, ErrBadArguments) {
+		t.Fatalf("Init(nil): got %v, want ErrBadArguments", err)
+	}
+
+	servers := []string{"a:1", "b:1", "c:1", "d:1", "e:1"}
+	orig := append([]string(nil), servers...)
+	orders := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		if err := hp.Init(servers); err != nil {
+			t.Fatal(err)
+		}
+		if hp.Len() != len(servers) {
+			t.Fatalf("Len: %d", hp.Len())
+		}
+		// 一轮之内每个服务器恰好返回一次，之后按同样的顺序轮换
+		round := make([]string, hp.Len())
+		for j := range round {
+			round[j] = hp.Next()
+		}
+		for j := range round {
+			if s := hp.Next(); s != round[j] {
+				t.Fatalf("second round: got %s at %d, want %s", s, j, round[j])
+			}
+		}
+		orders[strings.Join(round, ",")] = true
+		sort.Strings(round)
+		if !reflect.DeepEqual(round, orig) {
+			t.Fatalf("round: %v", round)
+		}
+	}
+	if !reflect.DeepEqual(servers, orig) {
+		t.Fatalf("Init modified its argument: %v", servers)
+	}
+	if len(orders) < 2 {
+		t.Fatal("servers were not shuffled")
+	}
+}
+
+// 每次拨号都重新解析域名
+func TestDialServer(t *testing.T) {
+	srv := newServer(t)
+	_, port, _ := net.SplitHostPort(srv.Addr())
+	conn, err := dialServer(net.JoinHostPort("localhost", port), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if _, err := dialServer("no-such-host.invalid:2181", time.Second); err == nil {
+		t.Fatal("dial to an unresolvable host succeeded")
+	}
+}
+
+type listHostProvider struct {
+	servers []string
+	next    int
+	calls   int
+}
+
+func (hp *listHostProvider) Init(servers []string) error { return nil }
+func (hp *listHostProvider) Len() int                    { return len(hp.servers) }
+func (hp *listHostProvider) Next() string {
+	s := hp.servers[hp.next%len(hp.servers)]
+	hp.next++
+	hp.calls++
+	return s
+}
+
+// 连接失败后轮换到下一个服务器
+func TestHostProviderRotation(t *testing.T) {
+	srv := newServer(t)
+	dead := newServer(t)
+	deadAddr := dead.Addr()
+	dead.Close()
+
+	hp := &listHostProvider{servers: []string{deadAddr, srv.Addr()}}
+	cli := newClient(t, srv.Addr(), WithHostProvider(hp), WithDialTimeout(time.Second))
+	if hp.calls != 2 {
+		t.Fatalf("Next called %d times, want 2", hp.calls)
+	}
+	if _, _, err := cli.Get("/"); err != nil {
+		t.Fatal(err)
+	}
+}
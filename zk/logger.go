@@ -1,12 +1,92 @@
 package zk
 
 import (
+	"fmt"
 	"log"
-	"os"
+	"log/slog"
+	"strings"
+	"sync/atomic"
 )
 
-var logger *log.Logger
+// 日志接口，keyvals为交替出现的键和值，如"xid", 1, "opcode", 4，
+// *slog.Logger直接满足此接口
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// 默认不输出任何日志
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (nopLogger) Info(msg string, keyvals ...interface{})  {}
+func (nopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (nopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// 使用log/slog输出日志，l为空时使用slog.Default()
+func SlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return l
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+// 使用标准库log输出日志，格式为：级别 消息 键=值 ...，l为空时使用log.Default()
+func StdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &stdLogger{l}
+}
+
+func (s *stdLogger) output(level, msg string, keyvals []interface{}) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(&b, " %v=%v", keyvals[i], keyvals[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keyvals[i])
+		}
+	}
+	s.l.Output(3, b.String())
+}
+
+func (s *stdLogger) Debug(msg string, keyvals ...interface{}) { s.output("DEBUG", msg, keyvals) }
+func (s *stdLogger) Info(msg string, keyvals ...interface{})  { s.output("INFO", msg, keyvals) }
+func (s *stdLogger) Warn(msg string, keyvals ...interface{})  { s.output("WARN", msg, keyvals) }
+func (s *stdLogger) Error(msg string, keyvals ...interface{}) { s.output("ERROR", msg, keyvals) }
+
+// 在日志字段前加上会话ID及服务器地址
+func (zkCli *ZkCli) logFields(keyvals []interface{}) []interface{} {
+	zkCli.stateLock.Lock()
+	server := zkCli.server
+	zkCli.stateLock.Unlock()
+	fields := make([]interface{}, 0, len(keyvals)+4)
+	fields = append(fields, "session", fmt.Sprintf("0x%x", atomic.LoadInt64(&zkCli.sessionid)), "server", server)
+	return append(fields, keyvals...)
+}
+
+func (zkCli *ZkCli) logDebug(msg string, keyvals ...interface{}) {
+	zkCli.logger.Debug(msg, zkCli.logFields(keyvals)...)
+}
+
+func (zkCli *ZkCli) logInfo(msg string, keyvals ...interface{}) {
+	zkCli.logger.Info(msg, zkCli.logFields(keyvals)...)
+}
+
+func (zkCli *ZkCli) logWarn(msg string, keyvals ...interface{}) {
+	zkCli.logger.Warn(msg, zkCli.logFields(keyvals)...)
+}
 
-func init() {
-	logger = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
+func (zkCli *ZkCli) logError(msg string, keyvals ...interface{}) {
+	zkCli.logger.Error(msg, zkCli.logFields(keyvals)...)
 }
@@ -0,0 +1,92 @@
+package zk
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+// 记录所有日志的Logger
+type recordLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (r *recordLogger) add(level, msg string, keyvals []interface{}) {
+	r.mu.Lock()
+	r.entries = append(r.entries, logEntry{level, msg, keyvals})
+	r.mu.Unlock()
+}
+
+func (r *recordLogger) Debug(msg string, keyvals ...interface{}) { r.add("DEBUG", msg, keyvals) }
+func (r *recordLogger) Info(msg string, keyvals ...interface{})  { r.add("INFO", msg, keyvals) }
+func (r *recordLogger) Warn(msg string, keyvals ...interface{})  { r.add("WARN", msg, keyvals) }
+func (r *recordLogger) Error(msg string, keyvals ...interface{}) { r.add("ERROR", msg, keyvals) }
+
+func (r *recordLogger) find(msg string) (logEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.msg == msg {
+			return e, true
+		}
+	}
+	return logEntry{}, false
+}
+
+func TestLoggerFields(t *testing.T) {
+	srv := newServer(t)
+	rec := &recordLogger{}
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), WithLogger(rec), opt)
+	session := fmt.Sprintf("0x%x", cli.SessionID())
+
+	e, ok := rec.find("session established")
+	if !ok || len(e.keyvals) != 8 {
+		t.Fatalf("session established: %+v", e)
+	}
+	// 每条日志都以会话ID及服务器地址开头，之后是调用处给出的字段
+	want := []interface{}{"session", session, "server", srv.Addr(), "timeout", e.keyvals[5], "readOnly", false}
+	if e.level != "INFO" || fmt.Sprint(e.keyvals) != fmt.Sprint(want) {
+		t.Fatalf("session established: %s %v, want fields %v", e.level, e.keyvals, want)
+	}
+
+	srv.Disconnect(cli.SessionID())
+	wait(t, SessionReconnected)
+	e, ok = rec.find("connection lost")
+	if !ok || e.level != "WARN" || len(e.keyvals) != 6 || e.keyvals[1] != session || e.keyvals[4] != "err" {
+		t.Fatalf("connection lost: %+v", e)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := StdLogger(log.New(&buf, "", 0))
+	l.Info("session established", "session", "0x1", "timeout", 4000)
+	l.Warn("odd", "key")
+	want := "INFO session established session=0x1 timeout=4000\nWARN odd key\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l.Debug("send request", "xid", 1, "opcode", 4)
+	if out := buf.String(); !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, `msg="send request" xid=1 opcode=4`) {
+		t.Fatalf("slog output: %q", out)
+	}
+	// 不设置或设置为空时不输出
+	New(WithLogger(nil)).logInfo("nothing")
+}
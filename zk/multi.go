@@ -0,0 +1,203 @@
+package zk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+// 事务中的一个操作，body为不含请求头的请求体
+type multiOp struct {
+	opcode int32
+	body   interface{}
+}
+
+// 每个操作前有一个操作头，最后以done为true的操作头结束
+type multiRequest struct {
+	ops []multiOp
+}
+
+func (req *multiRequest) Serialize(e *jute.Encoder) error {
+	for _, op := range req.ops {
+		if err := e.Encode(&proto.MultiHeader{Type: op.opcode, Done: false, Err: -1}); err != nil {
+			return err
+		}
+		if err := e.Encode(op.body); err != nil {
+			return err
+		}
+	}
+	return e.Encode(&proto.MultiHeader{Type: -1, Done: true, Err: -1})
+}
+
+// 事务中单个操作的结果
+type MultiResult struct {
+	Path string // 新建节点的实际路径，仅新建操作有
+	Stat *Stat  // 节点状态，设置操作及新建容器节点、TTL节点操作有
+	Err  error  // 操作的错误，事务成功时为空
+}
+
+type multiResponse struct {
+	results []MultiResult
+	errcode []int32
+}
+
+func (res *multiResponse) Deserialize(d *jute.Decoder) error {
+	for {
+		h := &proto.MultiHeader{}
+		if err := d.Decode(h); err != nil {
+			return err
+		}
+		if h.Done {
+			return nil
+		}
+		r := MultiResult{}
+		code := int32(errOk)
+		switch h.Type {
+		case opCreate:
+			c := &proto.CreateResponse{}
+			if err := d.Decode(c); err != nil {
+				return err
+			}
+			r.Path = c.Path
+		case opCreate2, opCreateContainer, opCreateTTL:
+			// 容器节点及TTL节点返回的结果还带有节点状态
+			c := &proto.Create2Response{}
+			if err := d.Decode(c); err != nil {
+				return err
+			}
+			stat := Stat(c.Stat)
+			r.Path, r.Stat = c.Path, &stat
+		case opSet:
+			c := &proto.SetDataResponse{}
+			if err := d.Decode(c); err != nil {
+				return err
+			}
+			stat := Stat(c.Stat)
+			r.Stat = &stat
+		case -1:
+			c := &proto.ErrorResponse{}
+			if err := d.Decode(c); err != nil {
+				return err
+			}
+			code = c.Err
+			if code != errOk {
+				r.Err = codeToError(code)
+			}
+		}
+		res.results = append(res.results, r)
+		res.errcode = append(res.errcode, code)
+	}
+}
+
+// 事务执行失败时返回的错误，Index为第一个失败操作的下标
+type MultiError struct {
+	Index int
+	Err   error
+}
+
+func (e *MultiError) Error() string {
+	return fmt.Sprintf("zk: multi op %d failed: %v", e.Index, e.Err)
+}
+
+func (e *MultiError) Unwrap() error {
+	return e.Err
+}
+
+// 事务构造器
+type Txn struct {
+	zkCli *ZkCli
+	ops   []multiOp
+	err   error // 构造时发现的参数错误，提交时返回
+}
+
+func (txn *Txn) add(opcode int32, body interface{}) *Txn {
+	txn.ops = append(txn.ops, multiOp{opcode, body})
+	return txn
+}
+
+// 添加新建节点操作，参数与ZkCli.Create相同，TTL节点请使用CreateTTL
+func (txn *Txn) Create(path string, data []byte, mode int32, acl []ACL) *Txn {
+	if isTTLMode(mode) {
+		txn.err = ErrBadArguments
+		return txn
+	}
+	return txn.add(newCreateRequest(txn.zkCli.serverPath(path), data, mode, acl, 0))
+}
+
+// 添加新建TTL节点操作，参数与ZkCli.CreateTTL相同
+func (txn *Txn) CreateTTL(path string, data []byte, mode int32, acl []ACL, ttl time.Duration) *Txn {
+	if !isTTLMode(mode) || ttl <= 0 {
+		txn.err = ErrBadArguments
+		return txn
+	}
+	return txn.add(newCreateRequest(txn.zkCli.serverPath(path), data, mode, acl, ttl))
+}
+
+// 添加设置节点数据操作，version为-1时不检查版本
+func (txn *Txn) Set(path string, data []byte, version int32) *Txn {
+	return txn.add(opSet, &proto.SetDataRequest{Path: txn.zkCli.serverPath(path), Data: data, Version: version})
+}
+
+// 添加删除节点操作，version为-1时不检查版本
+func (txn *Txn) Delete(path string, version int32) *Txn {
+	return txn.add(opDelete, &proto.DeleteRequest{Path: txn.zkCli.serverPath(path), Version: version})
+}
+
+// 添加版本检查操作，节点版本不等于version时整个事务失败
+func (txn *Txn) Check(path string, version int32) *Txn {
+	return txn.add(opCheck, &proto.CheckVersionRequest{Path: txn.zkCli.serverPath(path), Version: version})
+}
+
+// 提交事务，所有操作要么全部成功，要么全部不生效
+func (txn *Txn) Commit() ([]MultiResult, error) {
+	return txn.CommitContext(context.Background())
+}
+
+// 提交事务，ctx结束时放弃等待并返回ctx.Err()，此时事务可能已执行
+func (txn *Txn) CommitContext(ctx context.Context) ([]MultiResult, error) {
+	if txn.err != nil {
+		return nil, txn.err
+	}
+	return txn.zkCli.multi(ctx, txn.ops)
+}
+
+func (zkCli *ZkCli) multi(ctx context.Context, ops []multiOp) ([]MultiResult, error) {
+	req, err := zkCli.newRequest(opMulti, &multiRequest{ops}, nil)
+	if err != nil {
+		return nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		res := &multiResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			if req.resheader.Err != errOk {
+				// 整个事务被拒绝时只有响应头，如服务端只读
+				return nil, codeToError(req.resheader.Err)
+			}
+			return nil, err
+		}
+		for i := range res.results {
+			if res.results[i].Path != "" {
+				res.results[i].Path = zkCli.clientPath(res.results[i].Path)
+			}
+		}
+		for i, code := range res.errcode {
+			if code != errOk {
+				return res.results, &MultiError{i, res.results[i].Err}
+			}
+		}
+		if req.resheader.Err != errOk {
+			return res.results, codeToError(req.resheader.Err)
+		}
+		return res.results, nil
+	}
+	return nil, req.err
+}
+
+// API：新建一个事务，如zk.Multi().Create(...).Set(...).Commit()
+func (zk *ZkCli) Multi() *Txn {
+	return &Txn{zkCli: zk}
+}
@@ -0,0 +1,65 @@
+package zk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMulti(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	res, err := cli.Multi().
+		Create("/m", nil, CreatePersistent, nil).
+		Create("/m/seq-", nil, CreateSequential, nil).
+		Set("/m", []byte("x"), -1).
+		Check("/m", 1).
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 4 || res[0].Path != "/m" || res[1].Path != "/m/seq-0000000000" {
+		t.Fatalf("results: %+v", res)
+	}
+	if res[2].Stat == nil || res[2].Stat.Version != 1 {
+		t.Fatalf("set result: %+v", res[2])
+	}
+	for i, r := range res {
+		if r.Err != nil {
+			t.Fatalf("result %d: %v", i, r.Err)
+		}
+	}
+
+	res, err = cli.Multi().
+		Create("/m2", nil, CreatePersistent, nil).
+		Check("/m", 0).
+		Delete("/m/missing", -1).
+		Commit()
+	var me *MultiError
+	if !errors.As(err, &me) || me.Index != 1 || !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("failed multi: got %v", err)
+	}
+	if len(res) != 3 || res[0].Err != nil || !errors.Is(res[1].Err, ErrBadVersion) || !errors.Is(res[2].Err, ErrRuntimeInconsistency) {
+		t.Fatalf("failed multi results: %+v", res)
+	}
+	// 任何一个操作失败时所有操作都不生效
+	if ok, _, _ := cli.Exists("/m2"); ok {
+		t.Fatal("failed multi was partially applied")
+	}
+
+	if _, err := cli.Multi().Delete("/m", -1).Commit(); !errors.As(err, &me) || me.Index != 0 || !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("delete non-empty: got %v", err)
+	}
+}
+
+// 整个事务被拒绝时响应只有响应头，返回响应头中的错误
+func TestMultiRejected(t *testing.T) {
+	srv := newServer(t)
+	srv.SetReadOnly(true)
+	cli := newClient(t, srv.Addr(), WithReadOnly(true))
+
+	_, err := cli.Multi().Create("/a", nil, CreatePersistent, nil).Commit()
+	if !errors.Is(err, ErrNotReadOnly) {
+		t.Fatalf("multi on a read-only server: got %v, want ErrNotReadOnly", err)
+	}
+}
@@ -0,0 +1,83 @@
+package zk
+
+import (
+	"time"
+)
+
+// New的可选参数
+type Option func(*ZkCli)
+
+// 日志输出，默认不输出，可使用SlogLogger或StdLogger
+func WithLogger(l Logger) Option {
+	return func(zkCli *ZkCli) {
+		if l == nil {
+			l = nopLogger{}
+		}
+		zkCli.logger = l
+	}
+}
+
+// 会话状态监听者，按发生顺序在单独的goroutine中调用，应尽快返回
+func WithStateListener(fn func(SessionEvent)) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.stateListeners = append(zkCli.stateListeners, fn)
+	}
+}
+
+// 会话超时，实际值由服务端在[2*tickTime, 20*tickTime]范围内协商决定
+func WithSessionTimeout(timeout time.Duration) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.sessionTimeout = timeout
+	}
+}
+
+// 允许连接失去多数派后只读的服务器，此时只能执行读操作，并在后台寻找可读写的服务器
+func WithReadOnly(canBeReadOnly bool) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.canBeReadOnly = canBeReadOnly
+	}
+}
+
+// TCP拨号超时
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.dialTimeout = timeout
+	}
+}
+
+// 握手及认证时等待响应的超时，连接建立后的读超时由会话超时决定
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.readTimeout = timeout
+	}
+}
+
+// 写超时
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.writeTimeout = timeout
+	}
+}
+
+// 发送请求队列大小
+func WithQueueSize(size int) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.queueSize = size
+	}
+}
+
+// 最大包大小，应与服务端的jute.maxbuffer一致，超过它的请求及响应都返回ErrPacketTooLarge
+func WithMaxPacketSize(size int) Option {
+	return func(zkCli *ZkCli) {
+		zkCli.maxPacketSize = int32(size)
+	}
+}
+
+// 服务器地址提供者，默认打乱服务器顺序后轮流尝试
+func WithHostProvider(hp HostProvider) Option {
+	return func(zkCli *ZkCli) {
+		if hp != nil {
+			zkCli.hostProvider = hp
+		}
+	}
+}
@@ -1,20 +1,6 @@
 package zk
 
-type pingRequest struct {
-	xid    int32
-	opcode int32
-}
-
-func encodePingRequest(buf []byte, req *pingRequest) int32 {
-	Int32ToBytes(buf[4:], req.xid)
-	Int32ToBytes(buf[8:], req.opcode)
-	Int32ToBytes(buf[0:], 8)
-	return 12
-}
-
-type pingResponse struct {
-}
-
-func decodePingResponse(buf []byte, res *pingResponse) {
-
+// 心跳请求只有请求头，xid固定为-2
+func encodePingRequest() ([]byte, error) {
+	return encodeRequest(-2, opPing, nil)
 }
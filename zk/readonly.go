@@ -0,0 +1,60 @@
+package zk
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	minPingRwInterval = 100 * time.Millisecond // 寻找可读写服务器的初始间隔
+	maxPingRwInterval = 60 * time.Second       // 寻找可读写服务器的最大间隔
+)
+
+// 连接到只读服务器时在后台轮流询问其他服务器，找到可读写的服务器后断开当前连接，由重连优先连接它
+func (zkCli *ZkCli) searchRwServer(conn net.Conn, stop chan bool) {
+	interval := minPingRwInterval
+	next := rand.Intn(len(zkCli.servers)) // 单独的游标，从随机位置开始轮流询问
+	for {
+		select {
+		case <-time.After(interval):
+		case <-stop:
+			return
+		}
+		zkCli.stateLock.Lock()
+		current := zkCli.server
+		zkCli.stateLock.Unlock()
+		server := zkCli.servers[next]
+		next = (next + 1) % len(zkCli.servers)
+		if server != current && isRwServer(server, zkCli.dialTimeout) {
+			zkCli.logInfo("found read-write server", "addr", server)
+			zkCli.stateLock.Lock()
+			zkCli.rwServer = server
+			zkCli.stateLock.Unlock()
+			conn.Close()
+			return
+		}
+		if interval *= 2; interval > maxPingRwInterval {
+			interval = maxPingRwInterval
+		}
+	}
+}
+
+// 用isro四字命令询问服务器是否可读写
+func isRwServer(server string, timeout time.Duration) bool {
+	conn, err := dialServer(server, timeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write([]byte("isro")); err != nil {
+		return false
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return false
+	}
+	return string(buf) == "rw"
+}
@@ -0,0 +1,64 @@
+package zk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	srv := newServer(t)
+	srv.SetReadOnly(true)
+
+	if err := New().Connect([]string{srv.Addr()}); err == nil {
+		t.Fatal("client without WithReadOnly connected to a read-only server")
+	}
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), WithReadOnly(true), opt)
+	if cli.State() != SessionConnectedReadOnly {
+		t.Fatalf("state: %v", cli.State())
+	}
+	if _, _, err := cli.Get("/"); err != nil {
+		t.Fatalf("read on a read-only server: %v", err)
+	}
+	if _, err := cli.Create("/a", nil, CreatePersistent, nil); !errors.Is(err, ErrNotReadOnly) {
+		t.Fatalf("Create: got %v, want ErrNotReadOnly", err)
+	}
+
+	// 后台找到可读写的服务器后切换过去，只读服务器上的会话不能恢复，建立的是新会话
+	roSession := cli.SessionID()
+	srv.SetReadOnly(false)
+	wait(t, SessionConnected)
+	if cli.SessionID() == roSession {
+		t.Fatal("read-only session was resumed on a read-write server")
+	}
+	if _, err := cli.Create("/a", nil, CreatePersistent, nil); err != nil {
+		t.Fatalf("Create after leaving read-only: %v", err)
+	}
+}
+
+// 切换到可读写服务器后的新会话同样需要登记原来的监视
+func TestWatchesAfterLeavingReadOnly(t *testing.T) {
+	srv := newServer(t)
+	other := newClient(t, srv.Addr())
+	if _, err := other.Create("/ro", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetReadOnly(true)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), WithReadOnly(true), opt)
+	_, _, ch, err := cli.GetW("/ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetReadOnly(false)
+	wait(t, SessionConnected)
+	// 只读期间other的连接被断开，重新连接
+	other = newClient(t, srv.Addr())
+	if _, err := other.Set("/ro", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, ch); ev.Type != EventNodeDataChanged || ev.Path != "/ro" {
+		t.Fatalf("watch after leaving read-only: %+v", ev)
+	}
+}
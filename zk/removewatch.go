@@ -0,0 +1,89 @@
+package zk
+
+import (
+	"context"
+
+	"github.com/xianmau/gozk/proto"
+)
+
+// 要检查或删除的监视类型
+type WatcherType int32
+
+const (
+	WatcherChildren            WatcherType = 1 // ChildrenW登记的子节点监视
+	WatcherData                WatcherType = 2 // GetW及ExistsW登记的数据监视
+	WatcherAny                 WatcherType = 3 // 所有类型
+	WatcherPersistent          WatcherType = 4 // AddWatch登记的持久监视
+	WatcherPersistentRecursive WatcherType = 5 // AddWatch登记的递归持久监视
+)
+
+// 对应的客户端监视器类型
+func (t WatcherType) watchTypes() []int {
+	switch t {
+	case WatcherChildren:
+		return []int{watchTypeChild}
+	case WatcherData:
+		return []int{watchTypeData, watchTypeExist}
+	case WatcherPersistent:
+		return []int{watchTypePersistent}
+	case WatcherPersistentRecursive:
+		return []int{watchTypePersistentRecursive}
+	case WatcherAny:
+		return []int{watchTypeData, watchTypeExist, watchTypeChild, watchTypePersistent, watchTypePersistentRecursive}
+	}
+	return nil
+}
+
+func (zkCli *ZkCli) checkWatches(ctx context.Context, path string, wType WatcherType) (bool, error) {
+	req, err := zkCli.newRequest(opCheckWatches, &proto.CheckWatchesRequest{Path: zkCli.serverPath(path), Type: int32(wType)}, nil)
+	if err != nil {
+		return false, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		switch req.resheader.Err {
+		case errOk:
+			return true, nil
+		case errNoWatcher:
+			return false, nil
+		}
+		return false, codeToError(req.resheader.Err)
+	}
+	return false, req.err
+}
+
+func (zkCli *ZkCli) removeWatches(ctx context.Context, path string, wType WatcherType) error {
+	req, err := zkCli.newRequest(opRemoveWatches, &proto.RemoveWatchesRequest{Path: zkCli.serverPath(path), Type: int32(wType)}, nil)
+	if err != nil {
+		return err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return codeToError(req.resheader.Err)
+		}
+		zkCli.removeWatchers(path, wType.watchTypes())
+		return nil
+	}
+	return req.err
+}
+
+// API：检查服务端上是否登记了给定类型的监视
+func (zk *ZkCli) CheckWatches(path string, wType WatcherType) (bool, error) {
+	return zk.checkWatches(context.Background(), path, wType)
+}
+
+// API：检查监视，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) CheckWatchesContext(ctx context.Context, path string, wType WatcherType) (bool, error) {
+	return zk.checkWatches(ctx, path, wType)
+}
+
+// API：删除路径上给定类型的监视，对应的通道收到Event*WatchRemoved事件后关闭，没有监视时返回ErrNoWatcher
+func (zk *ZkCli) RemoveWatches(path string, wType WatcherType) error {
+	return zk.removeWatches(context.Background(), path, wType)
+}
+
+// API：删除监视，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) RemoveWatchesContext(ctx context.Context, path string, wType WatcherType) error {
+	return zk.removeWatches(ctx, path, wType)
+}
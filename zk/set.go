@@ -1,74 +1,72 @@
 package zk
 
-type setRequest struct {
-	xid     int32
-	opcode  int32
-	path    string
-	data    []byte
-	version int32
+import (
+	"context"
+	"errors"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+func (zkCli *ZkCli) set(ctx context.Context, path string, data []byte, version int32) (*Stat, error) {
+	req, err := zkCli.newRequest(opSet, &proto.SetDataRequest{Path: zkCli.serverPath(path), Data: data, Version: version}, nil)
+	if err != nil {
+		return nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return nil, codeToError(req.resheader.Err)
+		}
+		res := &proto.SetDataResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return nil, err
+		}
+		stat := Stat(res.Stat)
+		return &stat, nil
+	}
+	return nil, req.err
 }
 
-func encodeSetRequest(buf []byte, req *setRequest) int32 {
-	path := []byte(req.path)
-	path_len := int32(len(path))
-	data_len := int32(len(req.data))
-	n := 4
-	Int32ToBytes(buf[n:], req.xid)
-	n += 4
-	Int32ToBytes(buf[n:], req.opcode)
-	n += 4
-	Int32ToBytes(buf[n:], path_len)
-	n += 4
-	copy(buf[n:], path)
-	n += int(path_len)
-	Int32ToBytes(buf[n:], data_len)
-	n += 4
-	copy(buf[n:], req.data)
-	n += int(data_len)
-	Int32ToBytes(buf[n:], req.version)
-	Int32ToBytes(buf[0:], int32(n))
-	return int32(n + 4)
+// API：设置节点数据
+func (zk *ZkCli) Set(path string, data []byte) (*Stat, error) {
+	return zk.set(context.Background(), path, data, -1)
 }
 
-type setResponse struct {
-	// 这里还有节点的状态，但目前不关心
+// API：设置节点数据，ctx结束时放弃等待并返回ctx.Err()，此时数据可能已被修改
+func (zk *ZkCli) SetContext(ctx context.Context, path string, data []byte) (*Stat, error) {
+	return zk.set(ctx, path, data, -1)
 }
 
-func decodeSetResponse(buf []byte, res *setResponse) {
+// API：设置指定版本的节点数据，版本号不匹配时返回ErrBadVersion
+func (zk *ZkCli) SetVersion(path string, data []byte, version int32) (*Stat, error) {
+	return zk.set(context.Background(), path, data, version)
 }
 
-func (zkCli *ZkCli) set(path string, data []byte) error {
-	buf := make([]byte, BufferSize)
-	n := encodeSetRequest(buf, &setRequest{
-		xid:     zkCli.getNextXid(),
-		opcode:  opSet,
-		path:    path,
-		data:    data,
-		version: -1, // 默认
-	})
-	req := &request{
-		xid:    zkCli.xid,
-		opcode: opSet,
-		reqbuf: buf[:n],
-		resbuf: nil,
-		err:    nil,
-		done:   make(chan bool, 1),
-	}
-	zkCli.reqLock.Lock()
-	zkCli.reqMap[req.xid] = req
-	zkCli.reqLock.Unlock()
-	zkCli.sentchan <- req
-	<-req.done
-	if req.err == nil {
-		//res := &setResponse{}
-		//decodeSetResponse(req.res, res)
-		//logger.Println(req.resbuf)
-		return nil
-	}
-	return req.err
+// API：设置指定版本的节点数据，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) SetVersionContext(ctx context.Context, path string, data []byte, version int32) (*Stat, error) {
+	return zk.set(ctx, path, data, version)
 }
 
-// API：设置节点数据
-func (zk *ZkCli) Set(path string, data []byte) error {
-	return zk.set(path, data)
+// API：读取节点数据，经fn修改后按读到的版本号写回，版本冲突时重新读取并重试
+func (zk *ZkCli) Update(path string, fn func(data []byte) ([]byte, error)) (*Stat, error) {
+	return zk.UpdateContext(context.Background(), path, fn)
+}
+
+// API：同Update，ctx结束时停止重试并返回ctx.Err()
+func (zk *ZkCli) UpdateContext(ctx context.Context, path string, fn func(data []byte) ([]byte, error)) (*Stat, error) {
+	for {
+		data, stat, _, err := zk.get(ctx, path, false)
+		if err != nil {
+			return nil, err
+		}
+		data, err = fn(data)
+		if err != nil {
+			return nil, err
+		}
+		stat, err = zk.set(ctx, path, data, stat.Version)
+		if !errors.Is(err, ErrBadVersion) {
+			return stat, err
+		}
+	}
 }
@@ -0,0 +1,96 @@
+package zk
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/v", []byte("0"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := cli.SetVersion("/v", []byte("1"), 0)
+	if err != nil || stat.Version != 1 {
+		t.Fatalf("SetVersion: %+v, %v", stat, err)
+	}
+	if _, err := cli.SetVersion("/v", []byte("2"), 0); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("SetVersion stale: got %v, want ErrBadVersion", err)
+	}
+	if data, _, _ := cli.Get("/v"); string(data) != "1" {
+		t.Fatalf("data changed by a failed SetVersion: %q", data)
+	}
+	if err := cli.DeleteVersion("/v", 0); !errors.Is(err, ErrBadVersion) {
+		t.Fatalf("DeleteVersion stale: got %v, want ErrBadVersion", err)
+	}
+	if err := cli.DeleteVersion("/v", 1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, _ := cli.Exists("/v"); ok {
+		t.Fatal("node still exists after DeleteVersion")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+	other := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/counter", []byte("0"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 第一次读取后被其他客户端修改，写回时版本冲突，应重新读取并重试
+	calls := 0
+	stat, err := cli.Update("/counter", func(data []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			if _, err := other.Set("/counter", []byte("10")); err != nil {
+				t.Error(err)
+			}
+		}
+		n, _ := strconv.Atoi(string(data))
+		return []byte(strconv.Itoa(n + 1)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || stat.Version != 2 {
+		t.Fatalf("calls %d, stat %+v", calls, stat)
+	}
+	if data, _, _ := cli.Get("/counter"); string(data) != "11" {
+		t.Fatalf("data: %q", data)
+	}
+
+	// fn返回错误时不写回
+	errStop := errors.New("stop")
+	if _, err := cli.Update("/counter", func([]byte) ([]byte, error) { return nil, errStop }); err != errStop {
+		t.Fatalf("Update with failing fn: got %v", err)
+	}
+	if _, err := cli.Update("/missing", func(data []byte) ([]byte, error) { return data, nil }); !errors.Is(err, ErrNoNode) {
+		t.Fatalf("Update missing node: got %v, want ErrNoNode", err)
+	}
+
+	// 并发递增不会丢失更新
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := other.Update("/counter", func(data []byte) ([]byte, error) {
+				n, _ := strconv.Atoi(string(data))
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if data, _, _ := cli.Get("/counter"); string(data) != "21" {
+		t.Fatalf("after concurrent updates: %q", data)
+	}
+}
@@ -0,0 +1,38 @@
+package zk
+
+import (
+	"context"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+func (zkCli *ZkCli) setAcl(ctx context.Context, path string, acl []ACL, version int32) (*Stat, error) {
+	req, err := zkCli.newRequest(opSetAcl, &proto.SetACLRequest{Path: zkCli.serverPath(path), Acl: toProtoACL(acl), Version: version}, nil)
+	if err != nil {
+		return nil, err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return nil, codeToError(req.resheader.Err)
+		}
+		res := &proto.SetACLResponse{}
+		if _, err := jute.Unmarshal(req.resbuf, res); err != nil {
+			return nil, err
+		}
+		stat := Stat(res.Stat)
+		return &stat, nil
+	}
+	return nil, req.err
+}
+
+// API：设置节点的ACL，version为ACL版本号（Stat.Aversion），-1表示不检查
+func (zk *ZkCli) SetACL(path string, acl []ACL, version int32) (*Stat, error) {
+	return zk.setAcl(context.Background(), path, acl, version)
+}
+
+// API：设置节点的ACL，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) SetACLContext(ctx context.Context, path string, acl []ACL, version int32) (*Stat, error) {
+	return zk.setAcl(ctx, path, acl, version)
+}
@@ -0,0 +1,89 @@
+package zk
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/xianmau/gozk/proto"
+)
+
+const (
+	setWatchesXid      = -8
+	setWatchesMaxBatch = 128 * 1024 // 单个请求中路径的总长度上限，监视很多时分成多个请求
+)
+
+// 各列表都不能为空，空列表会编码为-1，服务端解析成null后处理失败
+func newSetWatches(zxid int64) *proto.SetWatches2 {
+	return &proto.SetWatches2{
+		RelativeZxid:               zxid,
+		DataWatches:                []string{},
+		ExistWatches:               []string{},
+		ChildWatches:               []string{},
+		PersistentWatches:          []string{},
+		PersistentRecursiveWatches: []string{},
+	}
+}
+
+// 重连后重新登记所有监视，服务端会对断开期间已经发生的变化立即触发事件
+// 只在重连时调用，此时收发循环还没有启动，响应由recvLoop处理
+func (zkCli *ZkCli) resendWatches() error {
+	zxid := atomic.LoadInt64(&zkCli.lastZxid)
+	var batches []*proto.SetWatches2
+	cur := newSetWatches(zxid)
+	size := 0
+	zkCli.watchLock.Lock()
+	for key, ws := range zkCli.watchers {
+		if len(ws) == 0 {
+			continue
+		}
+		path := zkCli.serverPath(key.path)
+		switch key.wType {
+		case watchTypeData:
+			cur.DataWatches = append(cur.DataWatches, path)
+		case watchTypeExist:
+			cur.ExistWatches = append(cur.ExistWatches, path)
+		case watchTypeChild:
+			cur.ChildWatches = append(cur.ChildWatches, path)
+		case watchTypePersistent:
+			cur.PersistentWatches = append(cur.PersistentWatches, path)
+		case watchTypePersistentRecursive:
+			cur.PersistentRecursiveWatches = append(cur.PersistentRecursiveWatches, path)
+		}
+		size += len(path)
+		if size >= setWatchesMaxBatch {
+			batches = append(batches, cur)
+			cur = newSetWatches(zxid)
+			size = 0
+		}
+	}
+	zkCli.watchLock.Unlock()
+	if size > 0 {
+		batches = append(batches, cur)
+	}
+
+	for _, batch := range batches {
+		var buf []byte
+		var err error
+		if len(batch.PersistentWatches) > 0 || len(batch.PersistentRecursiveWatches) > 0 {
+			// 持久监视需要3.6以上的服务端
+			buf, err = encodeRequest(setWatchesXid, opSetWatches2, batch)
+		} else {
+			buf, err = encodeRequest(setWatchesXid, opSetWatches, &proto.SetWatches{
+				RelativeZxid: batch.RelativeZxid,
+				DataWatches:  batch.DataWatches,
+				ExistWatches: batch.ExistWatches,
+				ChildWatches: batch.ChildWatches,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		zkCli.conn.SetWriteDeadline(time.Now().Add(zkCli.writeTimeout))
+		_, err = zkCli.conn.Write(buf)
+		zkCli.conn.SetWriteDeadline(time.Time{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package zk
+
+// 节点状态，字段与proto.Stat保持一致，以便直接转换
+type Stat struct {
+	Czxid          int64 // 创建节点的事务ID
+	Mzxid          int64 // 最后修改节点的事务ID
+	Ctime          int64 // 创建时间，单位：毫秒
+	Mtime          int64 // 最后修改时间，单位：毫秒
+	Version        int32 // 数据版本号
+	Cversion       int32 // 子节点版本号
+	Aversion       int32 // ACL版本号
+	EphemeralOwner int64 // 临时节点所属的会话ID，非临时节点为0
+	DataLength     int32 // 数据长度
+	NumChildren    int32 // 子节点个数
+	Pzxid          int64 // 最后修改子节点的事务ID
+}
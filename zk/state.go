@@ -0,0 +1,112 @@
+package zk
+
+import (
+	"sync/atomic"
+)
+
+// 会话状态
+type SessionState int32
+
+const (
+	SessionDisconnected SessionState = 0 // 还未连接，或首次连接失败
+	SessionConnecting   SessionState = 1 // 正在建立首次连接
+	SessionConnected    SessionState = 2 // 已连接
+	SessionSuspended    SessionState = 3 // 连接断开，正在重连，会话可能仍然有效
+	SessionReconnected  SessionState = 4 // 仅作为事件：重连成功并恢复了原来的会话，之后的状态为SessionConnected
+	SessionExpired      SessionState = 5 // 会话已过期，临时节点及监视都已失效，不会再重连
+	SessionAuthFailed   SessionState = 6 // 仅作为事件：认证失败，状态不变
+	SessionClosed       SessionState = 7 // 已主动关闭
+
+	SessionConnectedReadOnly SessionState = 8 // 已连接到只读服务器，写操作返回ErrNotReadOnly，需要WithReadOnly
+)
+
+var sessionStateNames = map[SessionState]string{
+	SessionDisconnected: "disconnected",
+	SessionConnecting:   "connecting",
+	SessionConnected:    "connected",
+	SessionSuspended:    "suspended",
+	SessionReconnected:  "reconnected",
+	SessionExpired:      "expired",
+	SessionAuthFailed:   "auth-failed",
+	SessionClosed:       "closed",
+
+	SessionConnectedReadOnly: "connected-read-only",
+}
+
+func (s SessionState) String() string {
+	if name, ok := sessionStateNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// 会话状态变化事件
+type SessionEvent struct {
+	State     SessionState
+	SessionID int64  // 会话ID，还未建立会话时为0
+	Server    string // 当前或最后连接的服务器
+}
+
+// 切换状态并通知监听者，SessionReconnected及SessionAuthFailed只通知不改变状态
+func (zkCli *ZkCli) setState(state SessionState) {
+	zkCli.stateLock.Lock()
+	defer zkCli.stateLock.Unlock()
+	switch state {
+	case SessionReconnected:
+		atomic.StoreInt32(&zkCli.state, int32(zkCli.connectedState()))
+	case SessionAuthFailed:
+	default:
+		if SessionState(atomic.LoadInt32(&zkCli.state)) == state {
+			return
+		}
+		atomic.StoreInt32(&zkCli.state, int32(state))
+	}
+	if len(zkCli.stateListeners) == 0 {
+		return
+	}
+	zkCli.stateEvents = append(zkCli.stateEvents, SessionEvent{
+		State:     state,
+		SessionID: atomic.LoadInt64(&zkCli.sessionid),
+		Server:    zkCli.server,
+	})
+	if !zkCli.delivering {
+		zkCli.delivering = true
+		go zkCli.deliverStateEvents()
+	}
+}
+
+// 按顺序把事件交给监听者，监听者中可以调用ZkCli的任何方法
+func (zkCli *ZkCli) deliverStateEvents() {
+	for {
+		zkCli.stateLock.Lock()
+		if len(zkCli.stateEvents) == 0 {
+			zkCli.delivering = false
+			zkCli.stateLock.Unlock()
+			return
+		}
+		ev := zkCli.stateEvents[0]
+		zkCli.stateEvents = zkCli.stateEvents[1:]
+		zkCli.stateLock.Unlock()
+		for _, fn := range zkCli.stateListeners {
+			fn(ev)
+		}
+	}
+}
+
+// 连接建立后的状态，取决于当前服务器是否只读
+func (zkCli *ZkCli) connectedState() SessionState {
+	if atomic.LoadInt32(&zkCli.readOnly) == 1 {
+		return SessionConnectedReadOnly
+	}
+	return SessionConnected
+}
+
+// API：当前会话状态
+func (zk *ZkCli) State() SessionState {
+	return SessionState(atomic.LoadInt32(&zk.state))
+}
+
+// API：当前会话ID，还未建立会话时为0
+func (zk *ZkCli) SessionID() int64 {
+	return atomic.LoadInt64(&zk.sessionid)
+}
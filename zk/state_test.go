@@ -0,0 +1,74 @@
+package zk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateEvents(t *testing.T) {
+	srv := newServer(t)
+	events := make(chan SessionEvent, 16)
+	cli := New(WithStateListener(func(ev SessionEvent) { events <- ev }))
+	next := func(want SessionState) SessionEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.State != want {
+				t.Fatalf("got state %v, want %v", ev.State, want)
+			}
+			return ev
+		case <-time.After(testTimeout):
+			t.Fatalf("timed out waiting for state %v", want)
+		}
+		return SessionEvent{}
+	}
+
+	if cli.State() != SessionDisconnected {
+		t.Fatalf("initial state: %v", cli.State())
+	}
+	if err := cli.Connect([]string{srv.Addr()}); err != nil {
+		t.Fatal(err)
+	}
+	id := cli.SessionID()
+	srv.Disconnect(id)
+
+	if ev := next(SessionConnecting); ev.SessionID != 0 {
+		t.Fatalf("connecting event: %+v", ev)
+	}
+	if ev := next(SessionConnected); ev.SessionID != id || ev.Server != srv.Addr() {
+		t.Fatalf("connected event: %+v", ev)
+	}
+	next(SessionSuspended)
+	if ev := next(SessionReconnected); ev.SessionID != id {
+		t.Fatalf("reconnected event: %+v", ev)
+	}
+	// SessionReconnected只作为事件，之后的状态为SessionConnected
+	if cli.State() != SessionConnected {
+		t.Fatalf("state after reconnect: %v", cli.State())
+	}
+	if err := cli.Close(); err != nil {
+		t.Fatal(err)
+	}
+	next(SessionClosed)
+	if cli.State() != SessionClosed {
+		t.Fatalf("state after Close: %v", cli.State())
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event after Close: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSessionStateString(t *testing.T) {
+	for state, want := range map[SessionState]string{
+		SessionConnected:         "connected",
+		SessionSuspended:         "suspended",
+		SessionConnectedReadOnly: "connected-read-only",
+		SessionState(100):        "unknown",
+	} {
+		if s := state.String(); s != want {
+			t.Errorf("%d: got %q, want %q", state, s, want)
+		}
+	}
+}
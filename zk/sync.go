@@ -0,0 +1,32 @@
+package zk
+
+import (
+	"context"
+
+	"github.com/xianmau/gozk/proto"
+)
+
+func (zkCli *ZkCli) sync(ctx context.Context, path string) error {
+	req, err := zkCli.newRequest(opSync, &proto.SyncRequest{Path: zkCli.serverPath(path)}, nil)
+	if err != nil {
+		return err
+	}
+	zkCli.sendRequest(ctx, req)
+	if req.err == nil {
+		if req.resheader.Err != errOk {
+			return codeToError(req.resheader.Err)
+		}
+		return nil
+	}
+	return req.err
+}
+
+// API：让当前连接的服务器与leader同步，之后的读操作能读到同步前已提交的数据
+func (zk *ZkCli) Sync(path string) error {
+	return zk.sync(context.Background(), path)
+}
+
+// API：与leader同步，ctx结束时放弃等待并返回ctx.Err()
+func (zk *ZkCli) SyncContext(ctx context.Context, path string) error {
+	return zk.sync(ctx, path)
+}
@@ -0,0 +1,24 @@
+package zk
+
+import (
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+	other := newClient(t, srv.Addr())
+
+	if _, err := other.Create("/synced", []byte("v"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Sync("/synced"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := cli.Get("/synced"); err != nil || string(data) != "v" {
+		t.Fatalf("Get after Sync: %q, %v", data, err)
+	}
+	if err := cli.Sync("/"); err != nil {
+		t.Fatal(err)
+	}
+}
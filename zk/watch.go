@@ -0,0 +1,250 @@
+package zk
+
+import (
+	"strings"
+	"sync"
+)
+
+type EventType int32
+
+const (
+	EventNone                   EventType = -1 // 会话状态变化，不针对节点
+	EventNodeCreated            EventType = 1  // 节点被创建
+	EventNodeDeleted            EventType = 2  // 节点被删除
+	EventNodeDataChanged        EventType = 3  // 节点数据改变
+	EventNodeChildrenChanged    EventType = 4  // 子节点列表改变
+	EventDataWatchRemoved       EventType = 5  // 数据监视被RemoveWatches删除
+	EventChildWatchRemoved      EventType = 6  // 子节点监视被RemoveWatches删除
+	EventPersistentWatchRemoved EventType = 7  // 持久监视被RemoveWatches删除
+)
+
+type KeeperState int32
+
+const (
+	StateUnknown           KeeperState = -1
+	StateDisconnected      KeeperState = 0
+	StateSyncConnected     KeeperState = 3
+	StateAuthFailed        KeeperState = 4
+	StateConnectedReadOnly KeeperState = 5
+	StateClosed            KeeperState = 7 // 客户端已关闭，只由客户端产生
+	StateExpired           KeeperState = -112
+)
+
+// 服务端推送过来的事件
+type Event struct {
+	Type  EventType
+	State KeeperState
+	Path  string
+}
+
+const (
+	watchTypeData                = 1
+	watchTypeExist               = 2
+	watchTypeChild               = 3
+	watchTypePersistent          = 4
+	watchTypePersistentRecursive = 5
+)
+
+type watchPathType struct {
+	path  string
+	wType int
+}
+
+type watcher struct {
+	path  string
+	wType int
+	ch    chan Event
+	queue *eventQueue // 持久监视的事件队列，一次性监视为空
+}
+
+func newWatcher(path string, wType int) *watcher {
+	return &watcher{
+		path:  path,
+		wType: wType,
+		ch:    make(chan Event, 1),
+	}
+}
+
+// 持久监视会收到多个事件，经过队列投递，避免阻塞接收循环
+func newPersistentWatcher(path string, wType int) *watcher {
+	ch := make(chan Event)
+	return &watcher{
+		path:  path,
+		wType: wType,
+		ch:    ch,
+		queue: &eventQueue{ch: ch},
+	}
+}
+
+// 投递事件，一次性监视投递后即关闭
+func (w *watcher) notify(ev Event) {
+	if w.queue != nil {
+		w.queue.push(ev)
+		return
+	}
+	w.ch <- ev
+	close(w.ch)
+}
+
+// 投递最后一个事件并关闭监视
+func (w *watcher) stop(ev Event) {
+	if w.queue != nil {
+		w.queue.push(ev)
+		w.queue.close()
+		return
+	}
+	w.ch <- ev
+	close(w.ch)
+}
+
+// 不限长度的事件队列，由单独的goroutine按顺序投递到ch，关闭后投递完剩余事件再关闭ch
+type eventQueue struct {
+	mu      sync.Mutex
+	events  []Event
+	closed  bool
+	running bool
+	ch      chan Event
+}
+
+func (q *eventQueue) push(ev Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.events = append(q.events, ev)
+	q.start()
+}
+
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.start()
+}
+
+// 调用时需持有q.mu
+func (q *eventQueue) start() {
+	if !q.running {
+		q.running = true
+		go q.deliver()
+	}
+}
+
+func (q *eventQueue) deliver() {
+	for {
+		q.mu.Lock()
+		if len(q.events) == 0 {
+			q.running = false
+			if q.closed {
+				close(q.ch)
+			}
+			q.mu.Unlock()
+			return
+		}
+		ev := q.events[0]
+		q.events = q.events[1:]
+		q.mu.Unlock()
+		q.ch <- ev
+	}
+}
+
+// 请求成功后登记监视器，只有在recvLoop中调用，保证在事件到达之前登记好
+func (zkCli *ZkCli) addWatcher(req *request) {
+	w := req.watcher
+	errcode := req.resheader.Err
+	if req.opcode == opExists && errcode == errNoNode {
+		// 节点不存在时监视节点的创建，存在时相当于监视数据
+		w.wType = watchTypeExist
+	} else if errcode != errOk {
+		return
+	}
+	key := watchPathType{w.path, w.wType}
+	zkCli.watchLock.Lock()
+	zkCli.watchers[key] = append(zkCli.watchers[key], w)
+	zkCli.watchLock.Unlock()
+}
+
+// 把事件分发给对应的监视器，一次性监视器触发后即删除，持久监视器一直保留
+func (zkCli *ZkCli) dispatchEvent(ev Event) {
+	var wTypes []int
+	switch ev.Type {
+	case EventNodeCreated:
+		wTypes = []int{watchTypeExist}
+	case EventNodeDeleted:
+		wTypes = []int{watchTypeExist, watchTypeData, watchTypeChild}
+	case EventNodeDataChanged:
+		wTypes = []int{watchTypeExist, watchTypeData}
+	case EventNodeChildrenChanged:
+		wTypes = []int{watchTypeChild}
+	default:
+		return
+	}
+	zkCli.watchLock.Lock()
+	defer zkCli.watchLock.Unlock()
+	for _, wType := range wTypes {
+		key := watchPathType{ev.Path, wType}
+		for _, w := range zkCli.watchers[key] {
+			w.notify(ev)
+		}
+		delete(zkCli.watchers, key)
+	}
+	for _, w := range zkCli.watchers[watchPathType{ev.Path, watchTypePersistent}] {
+		w.notify(ev)
+	}
+	if ev.Type == EventNodeChildrenChanged {
+		// 递归监视不关心子节点列表的变化，子节点的创建及删除会单独通知
+		return
+	}
+	for path := ev.Path; ; path = parentPath(path) {
+		for _, w := range zkCli.watchers[watchPathType{path, watchTypePersistentRecursive}] {
+			w.notify(ev)
+		}
+		if path == "/" {
+			break
+		}
+	}
+}
+
+// 删除并关闭给定路径上的监视器，监视器会先收到对应的删除事件
+func (zkCli *ZkCli) removeWatchers(path string, wTypes []int) {
+	zkCli.watchLock.Lock()
+	defer zkCli.watchLock.Unlock()
+	for _, wType := range wTypes {
+		key := watchPathType{path, wType}
+		ev := Event{Type: EventDataWatchRemoved, State: StateSyncConnected, Path: path}
+		switch wType {
+		case watchTypeChild:
+			ev.Type = EventChildWatchRemoved
+		case watchTypePersistent, watchTypePersistentRecursive:
+			ev.Type = EventPersistentWatchRemoved
+		}
+		for _, w := range zkCli.watchers[key] {
+			w.stop(ev)
+		}
+		delete(zkCli.watchers, key)
+	}
+}
+
+// 会话结束，通知并关闭所有监视器
+func (zkCli *ZkCli) closeWatchers(state KeeperState) {
+	zkCli.watchLock.Lock()
+	defer zkCli.watchLock.Unlock()
+	for key, ws := range zkCli.watchers {
+		for _, w := range ws {
+			w.stop(Event{Type: EventNone, State: state})
+		}
+		delete(zkCli.watchers, key)
+	}
+}
+
+func parentPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
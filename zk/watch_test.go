@@ -0,0 +1,205 @@
+package zk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWatches(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+	other := newClient(t, srv.Addr())
+
+	_, _, existCh, err := cli.ExistsW("/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Create("/w", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, existCh); ev.Type != EventNodeCreated || ev.Path != "/w" {
+		t.Fatalf("ExistsW: %+v", ev)
+	}
+
+	_, _, dataCh, err := cli.GetW("/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, childCh, err := cli.ChildrenW("/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Set("/w", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, dataCh); ev.Type != EventNodeDataChanged || ev.Path != "/w" {
+		t.Fatalf("GetW: %+v", ev)
+	}
+	if _, err := other.Create("/w/c", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, childCh); ev.Type != EventNodeChildrenChanged || ev.Path != "/w" {
+		t.Fatalf("ChildrenW: %+v", ev)
+	}
+	// 一次性监视触发后关闭
+	if _, ok := <-dataCh; ok {
+		t.Fatal("one-shot watch delivered a second event")
+	}
+
+	// 删除节点同时触发数据监视和子节点监视
+	_, _, dataCh, err = cli.GetW("/w/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, childCh, err = cli.ChildrenW("/w/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Delete("/w/c"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []<-chan Event{dataCh, childCh} {
+		if ev := waitEvent(t, ch); ev.Type != EventNodeDeleted || ev.Path != "/w/c" {
+			t.Fatalf("delete: %+v", ev)
+		}
+	}
+}
+
+func TestPersistentWatches(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	rec, err := cli.AddWatch("/", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	per, err := cli.AddWatch("/p", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Create("/p", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Create("/p/c", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Set("/p/c", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	// 持久监视触发后保留，收到节点本身及子节点列表的变化
+	for _, want := range []Event{
+		{Type: EventNodeCreated, State: StateSyncConnected, Path: "/p"},
+		{Type: EventNodeChildrenChanged, State: StateSyncConnected, Path: "/p"},
+	} {
+		if ev := waitEvent(t, per); ev != want {
+			t.Fatalf("persistent watch: got %+v, want %+v", ev, want)
+		}
+	}
+	// 递归监视收到整个子树的变化，不收到子节点列表的变化
+	for _, want := range []Event{
+		{Type: EventNodeCreated, State: StateSyncConnected, Path: "/p"},
+		{Type: EventNodeCreated, State: StateSyncConnected, Path: "/p/c"},
+		{Type: EventNodeDataChanged, State: StateSyncConnected, Path: "/p/c"},
+	} {
+		if ev := waitEvent(t, rec); ev != want {
+			t.Fatalf("recursive watch: got %+v, want %+v", ev, want)
+		}
+	}
+
+	if ok, err := cli.CheckWatches("/p", WatcherPersistent); !ok || err != nil {
+		t.Fatalf("CheckWatches: %v, %v", ok, err)
+	}
+	if err := cli.RemoveWatches("/p", WatcherPersistent); err != nil {
+		t.Fatal(err)
+	}
+	if ev := waitEvent(t, per); ev.Type != EventPersistentWatchRemoved {
+		t.Fatalf("removed watch: %+v", ev)
+	}
+	if _, ok := <-per; ok {
+		t.Fatal("removed watch channel not closed")
+	}
+	if ok, err := cli.CheckWatches("/p", WatcherPersistent); ok || err != nil {
+		t.Fatalf("CheckWatches after remove: %v, %v", ok, err)
+	}
+	if err := cli.RemoveWatches("/p", WatcherPersistent); !errors.Is(err, ErrNoWatcher) {
+		t.Fatalf("second RemoveWatches: got %v, want ErrNoWatcher", err)
+	}
+}
+
+// 会话过期后监视不会再被触发，通知后关闭
+func TestExpiryClosesWatches(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	_, _, ch, err := cli.ExistsW("/never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	per, err := cli.AddWatch("/", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ExpireSession(cli.SessionID())
+	wait(t, SessionExpired)
+	for _, c := range []<-chan Event{ch, per} {
+		if ev := waitEvent(t, c); ev.Type != EventNone || ev.State != StateExpired {
+			t.Fatalf("expiry event: %+v", ev)
+		}
+		if _, ok := <-c; ok {
+			t.Fatal("watch channel not closed after expiry")
+		}
+	}
+}
+
+// 重连后通过SetWatches重新登记监视，断开期间发生的变化也会触发
+func TestWatchesRestoredAfterReconnect(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	other := newClient(t, srv.Addr())
+	if _, err := other.Create("/r", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, _, dataCh, err := cli.GetW("/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, childCh, err := cli.ChildrenW("/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, existCh, err := cli.ExistsW("/r/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	per, err := cli.AddWatch("/r", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Disconnect(cli.SessionID())
+	if _, err := other.Set("/r", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, SessionReconnected)
+	if _, err := other.Create("/r/b", nil, CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		ch   <-chan Event
+		want Event
+	}{
+		{dataCh, Event{Type: EventNodeDataChanged, State: StateSyncConnected, Path: "/r"}},
+		{childCh, Event{Type: EventNodeChildrenChanged, State: StateSyncConnected, Path: "/r"}},
+		{existCh, Event{Type: EventNodeCreated, State: StateSyncConnected, Path: "/r/b"}},
+		// 服务端为断开期间的变化发送的事件同样分发给路径上的持久监视
+		{per, Event{Type: EventNodeDataChanged, State: StateSyncConnected, Path: "/r"}},
+		{per, Event{Type: EventNodeCreated, State: StateSyncConnected, Path: "/r/b"}},
+	} {
+		if ev := waitEvent(t, c.ch); ev != c.want {
+			t.Fatalf("got %+v, want %+v", ev, c.want)
+		}
+	}
+}
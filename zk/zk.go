@@ -1,46 +1,85 @@
 package zk
 
 import (
+	"context"
 	"net"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/xianmau/gozk/proto"
 )
 
+// 以下为默认值，可通过New的参数修改
 const (
-	DefaultPort    = 2181     // 默认端口号
-	RecvTimeout    = 1        // 接收消息超时，单位：秒
-	SessionTimeout = 4000     // 客户端会话超时，单位：毫秒
-	PingInterval   = 2000     // Ping超时,单位：毫秒
-	BufferSize     = 2 * 1024 // 1K
-	SentChanSize   = 16       // 发送请求队列大小
-	RecvChanSize   = 16       // 接收响应队列大小
+	DefaultPort          = 2181        // 默认端口号
+	RecvTimeout          = 1           // 接收消息超时，单位：秒
+	SessionTimeout       = 4000        // 客户端会话超时，单位：毫秒
+	DialTimeout          = 5000        // TCP拨号超时，单位：毫秒
+	ReconnectInterval    = 1000        // 所有服务器都连不上时，再次重连的初始间隔，单位：毫秒
+	MaxReconnectInterval = 16000       // 重连等待间隔指数增长的上限，单位：毫秒
+	SentChanSize         = 16          // 发送请求队列大小
+	RecvChanSize         = 16          // 接收响应队列大小
+	MaxPacketSize        = 1024 * 1024 // 最大包大小，与服务端jute.maxbuffer的默认值相当
 )
 
+// 响应除节点数据外还带有响应头、节点状态等，不超过最大包大小的数据读出来时整个响应可能会超过它，
+// 因此接收响应时在最大包大小之外留出这些余量
+const responseOverhead = 1024
+
 type ZkCli struct {
 	xid             int32              // 请求编号，用于映射请求响应
 	reqMap          map[int32]*request // 请求响应映射
 	reqLock         sync.Mutex         //请求锁，在操作请求映射可能需要加锁
 	protocolversion int32
-	sessiontimeout  int32
-	sessionid       int64
+	sessiontimeout  int32 // 与服务端协商后的会话超时，单位：毫秒
+	sessionid       int64 // 原子读写
 	password        []byte
-	conn            *net.TCPConn
-	state           int32
+	lastZxid        int64        // 最后收到的事务ID，重连时发给服务端
+	hostProvider    HostProvider // 服务器地址提供者
+	servers         []string     // Connect时给出的服务器，寻找可读写服务器时使用，不影响hostProvider的轮换
+	chroot          string       // 所有路径的前缀，为空时没有chroot
+	canBeReadOnly   bool         // 是否允许连接只读服务器
+	readOnly        int32        // 当前连接的服务器是否只读，原子读写
+	seenRwServer    bool         // 是否连接过可读写的服务器，之前只连过只读服务器时的会话不能恢复
+	rwServer        string       // 后台找到的可读写服务器，下次重连时优先尝试，由stateLock保护
+	closed          int32        // 是否已主动关闭
+	conn            net.Conn
+	state           int32  // SessionState，原子读写
+	server          string // 当前连接的服务器
+	stateLock       sync.Mutex
+	stateListeners  []func(SessionEvent) // 会话状态监听者
+	stateEvents     []SessionEvent       // 待通知的状态变化
+	delivering      bool                 // 是否正在通知
+	logger          Logger
 	sentchan        chan *request
+	closechan       chan bool                    // 主动关闭时关闭此通道
+	watchers        map[watchPathType][]*watcher // 监视器
+	watchLock       sync.Mutex                   // 监视器锁
+	auths           []authInfo                   // 已添加的认证信息
+	authLock        sync.Mutex                   // 认证信息锁
+	authReqLock     sync.Mutex                   // 保证同一时间只有一个认证请求
+	sessionTimeout  time.Duration                // 请求的会话超时
+	dialTimeout     time.Duration                // TCP拨号超时
+	readTimeout     time.Duration                // 握手及认证时等待响应的超时
+	writeTimeout    time.Duration                // 写超时
+	queueSize       int                          // 发送请求队列大小
+	maxPacketSize   int32                        // 最大包大小
 }
 
 type request struct {
-	xid       int32           //
-	opcode    int32           //
-	reqbuf    []byte          // 用于直接发送的字节数组
-	resheader *responseHeader //
-	resbuf    []byte          // 直接接收到的字节数组
-	err       error           // 错误信息
-	done      chan bool       // 是否处理完成
+	xid       int32              //
+	opcode    int32              //
+	reqbuf    []byte             // 用于直接发送的字节数组
+	resheader *proto.ReplyHeader //
+	resbuf    []byte             // 直接接收到的字节数组
+	err       error              // 错误信息
+	done      chan bool          // 是否处理完成
+	watcher   *watcher           // 需要登记的监视器，可为空
 }
 
-// API：新建一个实例
-func New() *ZkCli {
+// API：新建一个实例，如New(zk.WithSessionTimeout(10*time.Second))
+func New(opts ...Option) *ZkCli {
 	zkCli := ZkCli{
 		xid:             0,
 		reqMap:          make(map[int32]*request),
@@ -49,12 +88,124 @@ func New() *ZkCli {
 		sessionid:       0,
 		password:        []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 		conn:            nil,
-		state:           stateDisconnect,
-		sentchan:        make(chan *request, SentChanSize),
+		state:           int32(SessionDisconnected),
+		closechan:       make(chan bool),
+		watchers:        make(map[watchPathType][]*watcher),
+		sessionTimeout:  SessionTimeout * time.Millisecond,
+		dialTimeout:     DialTimeout * time.Millisecond,
+		readTimeout:     RecvTimeout * time.Second,
+		writeTimeout:    RecvTimeout * time.Second,
+		queueSize:       SentChanSize,
+		maxPacketSize:   MaxPacketSize,
+		logger:          nopLogger{},
+		hostProvider:    &shuffleHostProvider{},
+	}
+	for _, opt := range opts {
+		opt(&zkCli)
 	}
+	zkCli.sentchan = make(chan *request, zkCli.queueSize)
 	return &zkCli
 }
 
 func (zkCli *ZkCli) getNextXid() int32 {
 	return atomic.AddInt32(&zkCli.xid, 1)
 }
+
+// 分配xid并编码请求，w为需要登记的监视器，可为空
+func (zkCli *ZkCli) newRequest(opcode int32, body interface{}, w *watcher) (*request, error) {
+	xid := zkCli.getNextXid()
+	buf, err := encodeRequest(xid, opcode, body)
+	if err != nil {
+		return nil, err
+	}
+	return &request{
+		xid:     xid,
+		opcode:  opcode,
+		reqbuf:  buf,
+		resbuf:  nil,
+		err:     nil,
+		done:    make(chan bool, 1),
+		watcher: w,
+	}, nil
+}
+
+// 登记请求并放入发送队列，等待响应、连接断开或ctx结束
+func (zkCli *ZkCli) sendRequest(ctx context.Context, req *request) error {
+	if int32(len(req.reqbuf)) > zkCli.maxPacketSize {
+		req.err = ErrPacketTooLarge
+		return req.err
+	}
+	zkCli.reqLock.Lock()
+	if atomic.LoadInt32(&zkCli.closed) == 1 {
+		zkCli.reqLock.Unlock()
+		if zkCli.State() == SessionExpired {
+			req.err = ErrSessionExpired
+		} else {
+			req.err = ErrClosed
+		}
+		return req.err
+	}
+	if state := zkCli.State(); state == SessionDisconnected || state == SessionConnecting {
+		// 还没有连接成功，连接守护没有运行，请求不会被发送也不会被结束
+		zkCli.reqLock.Unlock()
+		req.err = ErrConnectionClosed
+		return req.err
+	}
+	zkCli.reqMap[req.xid] = req
+	zkCli.reqLock.Unlock()
+	select {
+	case zkCli.sentchan <- req:
+	case <-req.done:
+		// 还没进入发送队列请求就已经结束
+		return req.err
+	case <-ctx.Done():
+		return zkCli.cancelRequest(req, ctx.Err())
+	}
+	select {
+	case <-req.done:
+	case <-ctx.Done():
+		return zkCli.cancelRequest(req, ctx.Err())
+	}
+	return req.err
+}
+
+// 放弃等待请求的响应，如果响应已经到达则仍返回响应的结果
+func (zkCli *ZkCli) cancelRequest(req *request, err error) error {
+	zkCli.reqLock.Lock()
+	_, ok := zkCli.reqMap[req.xid]
+	if ok {
+		delete(zkCli.reqMap, req.xid)
+	}
+	zkCli.reqLock.Unlock()
+	if !ok {
+		<-req.done
+		return req.err
+	}
+	req.err = err
+	return err
+}
+
+// 以err结束所有未完成的请求
+func (zkCli *ZkCli) flushRequests(err error) {
+	zkCli.reqLock.Lock()
+	for xid, req := range zkCli.reqMap {
+		req.err = err
+		req.done <- true
+		delete(zkCli.reqMap, xid)
+	}
+	zkCli.reqLock.Unlock()
+}
+
+// 连接守护退出，之后的请求都直接返回错误
+func (zkCli *ZkCli) shutdown(err error) {
+	zkCli.reqLock.Lock()
+	atomic.StoreInt32(&zkCli.closed, 1)
+	zkCli.reqLock.Unlock()
+	zkCli.flushRequests(err)
+	// 监视器不会再被触发，通知后关闭
+	if err == ErrSessionExpired {
+		zkCli.closeWatchers(StateExpired)
+	} else {
+		zkCli.closeWatchers(StateClosed)
+	}
+}
@@ -0,0 +1,263 @@
+package zk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xianmau/gozk/proto"
+	"github.com/xianmau/gozk/zktest"
+)
+
+// 测试等待事件或状态的超时
+const testTimeout = 5 * time.Second
+
+func newServer(t *testing.T) *zktest.Server {
+	t.Helper()
+	srv, err := zktest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// 连接到addr，测试结束时关闭
+func newClient(t *testing.T, addr string, opts ...Option) *ZkCli {
+	t.Helper()
+	cli := New(opts...)
+	if err := cli.Connect([]string{addr}); err != nil {
+		t.Fatalf("Connect(%s): %v", addr, err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+func waitEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("watch channel closed without an event")
+		}
+		return ev
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a watch event")
+	}
+	return Event{}
+}
+
+func TestStat(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+
+	if _, err := cli.Create("/s", []byte("abc"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, stat, err := cli.Get("/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.DataLength != 3 || stat.Version != 0 || stat.Czxid == 0 || stat.Czxid != stat.Mzxid || stat.Ctime == 0 {
+		t.Fatalf("Get: %+v", stat)
+	}
+	created := *stat
+
+	stat, err = cli.Set("/s", []byte("abcd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Version != 1 || stat.DataLength != 4 || stat.Czxid != created.Czxid || stat.Mzxid <= created.Mzxid {
+		t.Fatalf("Set: %+v", stat)
+	}
+	ok, existStat, err := cli.Exists("/s")
+	if err != nil || !ok || *existStat != *stat {
+		t.Fatalf("Exists: %v, %+v, %v", ok, existStat, err)
+	}
+
+	if _, err := cli.Create("/s/c", nil, CreateEphemeral, nil); err != nil {
+		t.Fatal(err)
+	}
+	children, stat, err := cli.Children2("/s")
+	if err != nil || len(children) != 1 {
+		t.Fatalf("Children2: %v, %v", children, err)
+	}
+	if stat.NumChildren != 1 || stat.Cversion != 1 || stat.Pzxid <= created.Pzxid {
+		t.Fatalf("Children2 stat: %+v", stat)
+	}
+	_, stat, err = cli.Get("/s/c")
+	if err != nil || stat.EphemeralOwner != cli.SessionID() {
+		t.Fatalf("ephemeral owner: %+v, %v", stat, err)
+	}
+}
+
+// 返回记录状态变化的选项，以及等待某个状态出现的函数
+func stateWaiter() (Option, func(t *testing.T, state SessionState)) {
+	events := make(chan SessionEvent, 64)
+	opt := WithStateListener(func(ev SessionEvent) { events <- ev })
+	wait := func(t *testing.T, state SessionState) {
+		t.Helper()
+		timeout := time.After(testTimeout)
+		for {
+			select {
+			case ev := <-events:
+				if ev.State == state {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for state %v", state)
+			}
+		}
+	}
+	return opt, wait
+}
+
+// fn应在testTimeout内返回，否则测试失败
+func mustReturn(t *testing.T, name string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatalf("%s blocked", name)
+	}
+}
+
+// 还在等待响应的请求数
+func pendingRequests(cli *ZkCli) int {
+	cli.reqLock.Lock()
+	defer cli.reqLock.Unlock()
+	return len(cli.reqMap)
+}
+
+func watcherCount(cli *ZkCli) int {
+	cli.watchLock.Lock()
+	defer cli.watchLock.Unlock()
+	n := 0
+	for _, ws := range cli.watchers {
+		n += len(ws)
+	}
+	return n
+}
+
+// 还在发送队列中时取消，请求不会再被发送
+func TestCancelQueued(t *testing.T) {
+	srv := newServer(t)
+	opt, wait := stateWaiter()
+	cli := newClient(t, srv.Addr(), opt)
+	srv.Stop()
+	wait(t, SessionSuspended)
+	// 等第一轮重连失败，下一轮重连成功之前请求留在发送队列中
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := cli.CreateContext(ctx, "/queued", nil, CreatePersistent, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateContext: got %v, want DeadlineExceeded", err)
+	}
+	if n := len(cli.sentchan); n != 1 {
+		t.Fatalf("%d requests queued, want 1", n)
+	}
+	if n := pendingRequests(cli); n != 0 {
+		t.Fatalf("%d requests still pending after cancel", n)
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, SessionReconnected)
+	// 发送队列按顺序发送，之后的请求返回时，取消的请求如果被发送了也已经执行
+	if ok, _, err := cli.Exists("/queued"); ok || err != nil {
+		t.Fatalf("cancelled request was sent: %v, %v", ok, err)
+	}
+}
+
+// 已发送后取消，迟到的响应被丢弃
+func TestCancelAfterSend(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+	if _, err := cli.Create("/n", []byte("v"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetDelay(300 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, ch, err := cli.GetWContext(ctx, "/n"); !errors.Is(err, context.DeadlineExceeded) || ch != nil {
+		t.Fatalf("GetWContext: got %v, %v, want DeadlineExceeded", ch, err)
+	}
+	if n := pendingRequests(cli); n != 0 {
+		t.Fatalf("%d requests still pending after cancel", n)
+	}
+
+	// 服务端按顺序处理，这个请求返回时迟到的响应已经到达，不应被当成它的响应，也不应登记监视
+	srv.SetDelay(0)
+	if _, err := cli.Set("/n", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := cli.Get("/n"); err != nil || string(data) != "v2" {
+		t.Fatalf("Get after cancel: %q, %v", data, err)
+	}
+	if n := watcherCount(cli); n != 0 {
+		t.Fatalf("%d watchers registered by a cancelled request", n)
+	}
+}
+
+// 取消时响应已经到达，返回响应的结果
+func TestCancelAfterResponse(t *testing.T) {
+	srv := newServer(t)
+	cli := newClient(t, srv.Addr())
+	if _, err := cli.Create("/n", []byte("v"), CreatePersistent, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 接收循环已经取出请求并交付响应，之后调用者的ctx才结束
+	req, err := cli.newRequest(opGet, &proto.GetDataRequest{Path: "/n"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.reqLock.Lock()
+	cli.reqMap[req.xid] = req
+	delete(cli.reqMap, req.xid)
+	req.resheader = &proto.ReplyHeader{Xid: req.xid}
+	req.done <- true
+	cli.reqLock.Unlock()
+	if err := cli.cancelRequest(req, context.Canceled); err != nil || req.err != nil {
+		t.Fatalf("cancelRequest after the response: %v, %v", err, req.err)
+	}
+
+	// 响应与取消同时到达，结果要么是响应，要么是ctx的错误
+	srv.SetDelay(5 * time.Millisecond)
+	results := map[bool]int{}
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%10)*time.Millisecond)
+		data, stat, err := cli.GetContext(ctx, "/n")
+		cancel()
+		if err != nil {
+			// 等服务端处理完，避免延迟累积
+			time.Sleep(10 * time.Millisecond)
+		}
+		switch {
+		case err == nil:
+			if string(data) != "v" || stat == nil {
+				t.Fatalf("Get #%d: %q, %+v", i, data, stat)
+			}
+		case !errors.Is(err, context.DeadlineExceeded):
+			t.Fatalf("Get #%d: %v", i, err)
+		}
+		results[err == nil]++
+	}
+	srv.SetDelay(0)
+	if data, _, err := cli.Get("/n"); err != nil || string(data) != "v" {
+		t.Fatalf("Get after cancels: %q, %v", data, err)
+	}
+	if n := pendingRequests(cli); n != 0 {
+		t.Fatalf("%d requests still pending", n)
+	}
+	t.Logf("completed %d, cancelled %d", results[true], results[false])
+}
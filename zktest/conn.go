@@ -0,0 +1,567 @@
+package zktest
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+func (s *Server) serveConn(c net.Conn) {
+	defer s.wg.Done()
+	defer c.Close()
+	var sess *session
+	defer func() {
+		s.detach(sess, c)
+	}()
+
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(c, sizeBuf[:]); err != nil {
+		return
+	}
+	if string(sizeBuf[:]) == "isro" {
+		// 四字命令，客户端据此寻找可读写的服务端
+		s.mu.Lock()
+		readOnly := s.readOnly
+		s.mu.Unlock()
+		if readOnly {
+			c.Write([]byte("ro"))
+		} else {
+			c.Write([]byte("rw"))
+		}
+		return
+	}
+
+	// 连接认证
+	buf, err := readPacketBody(c, sizeBuf)
+	if err != nil {
+		return
+	}
+	req := &proto.ConnectRequest{}
+	if _, err := jute.Unmarshal(buf, req); err != nil {
+		return
+	}
+	s.mu.Lock()
+	reject := s.readOnly && !req.ReadOnly
+	s.mu.Unlock()
+	if reject {
+		// 只读服务端直接断开不允许只读的客户端
+		return
+	}
+	sess = s.attach(req, c)
+	s.mu.Lock()
+	if sess == nil {
+		// 会话已过期，超时为0
+		send(c, &proto.ConnectResponse{Passwd: make([]byte, 16)})
+		s.mu.Unlock()
+		return
+	}
+	err = send(c, &proto.ConnectResponse{
+		TimeOut:   int32(sess.timeout / time.Millisecond),
+		SessionId: sess.id,
+		Passwd:    sess.password,
+		ReadOnly:  s.readOnly,
+	})
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	for {
+		buf, err := readPacket(c)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		delay := s.delay
+		s.mu.Unlock()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		d := jute.NewDecoder(buf)
+		header := &proto.RequestHeader{}
+		if err := d.Decode(header); err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.sessions[sess.id] != sess || sess.conn != c {
+			// 会话已过期或已转移到其他连接
+			s.mu.Unlock()
+			return
+		}
+		sess.lastSeen = time.Now()
+		code, res := s.handle(sess, header.Type, d)
+		reply := &proto.ReplyHeader{Xid: header.Xid, Zxid: s.zxid, Err: code}
+		if res != nil && (code == errOk || header.Type == opMulti) {
+			err = send(c, reply, res)
+		} else {
+			err = send(c, reply)
+		}
+		if header.Type == opClose {
+			s.closeSession(sess)
+		}
+		s.mu.Unlock()
+		if err != nil || header.Type == opClose {
+			return
+		}
+	}
+}
+
+// 处理一个请求，返回错误码及响应体，调用时需持有s.mu
+func (s *Server) handle(sess *session, opcode int32, d *jute.Decoder) (int32, interface{}) {
+	if s.readOnly && isWrite(opcode) {
+		return errNotReadOnly, nil
+	}
+	switch opcode {
+	case opPing, opClose:
+		return errOk, nil
+	case opAuth:
+		req := &proto.AuthPacket{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		return s.auth(sess, req), nil
+	case opCreate, opCreate2, opCreateContainer, opCreateTTL:
+		req, err := decodeCreate(opcode, d)
+		if err != nil {
+			return errMarshallingError, nil
+		}
+		var res interface{}
+		code := s.write(sess, func(t *tree, tx *txn) int32 {
+			path, stat, code := t.create(tx, req.Path, req.Data, req.Acl, req.Flags)
+			if code == errOk {
+				res = createResult(opcode, path, stat)
+			}
+			return code
+		})
+		return code, res
+	case opDelete:
+		req := &proto.DeleteRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		return s.write(sess, func(t *tree, tx *txn) int32 {
+			return t.delete(tx, req.Path, req.Version)
+		}), nil
+	case opSet:
+		req := &proto.SetDataRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		res := &proto.SetDataResponse{}
+		return s.write(sess, func(t *tree, tx *txn) int32 {
+			stat, code := t.set(tx, req.Path, req.Data, req.Version)
+			if code == errOk {
+				res.Stat = *stat
+			}
+			return code
+		}), res
+	case opSetAcl:
+		req := &proto.SetACLRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		res := &proto.SetACLResponse{}
+		return s.write(sess, func(t *tree, tx *txn) int32 {
+			stat, code := t.setACL(tx, req.Path, req.Acl, req.Version)
+			if code == errOk {
+				res.Stat = *stat
+			}
+			return code
+		}), res
+	case opExists:
+		req := &proto.ExistsRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		n, ok := s.tree.nodes[req.Path]
+		if req.Watch {
+			// 节点不存在时同样登记，节点创建时触发
+			addWatch(s.dataWatches, req.Path, sess.id)
+		}
+		if !ok {
+			return errNoNode, nil
+		}
+		return errOk, &proto.ExistsResponse{Stat: n.stat}
+	case opGet:
+		req := &proto.GetDataRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		n, ok := s.tree.nodes[req.Path]
+		if !ok {
+			return errNoNode, nil
+		}
+		if req.Watch {
+			addWatch(s.dataWatches, req.Path, sess.id)
+		}
+		return errOk, &proto.GetDataResponse{Data: n.data, Stat: n.stat}
+	case opChildren, opChildren2:
+		req := &proto.GetChildrenRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		n, ok := s.tree.nodes[req.Path]
+		if !ok {
+			return errNoNode, nil
+		}
+		if req.Watch {
+			addWatch(s.childWatches, req.Path, sess.id)
+		}
+		children := make([]string, 0, len(n.children))
+		for child := range n.children {
+			children = append(children, child)
+		}
+		if opcode == opChildren2 {
+			return errOk, &proto.GetChildren2Response{Children: children, Stat: n.stat}
+		}
+		return errOk, &proto.GetChildrenResponse{Children: children}
+	case opGetAcl:
+		req := &proto.GetACLRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		n, ok := s.tree.nodes[req.Path]
+		if !ok {
+			return errNoNode, nil
+		}
+		return errOk, &proto.GetACLResponse{Acl: n.acl, Stat: n.stat}
+	case opSync:
+		req := &proto.SyncRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		return errOk, &proto.SyncResponse{Path: req.Path}
+	case opMulti:
+		return s.multi(sess, d)
+	case opSetWatches, opSetWatches2:
+		req := &proto.SetWatches2{}
+		if opcode == opSetWatches {
+			old := &proto.SetWatches{}
+			if err := d.Decode(old); err != nil {
+				return errMarshallingError, nil
+			}
+			req.RelativeZxid, req.DataWatches, req.ExistWatches, req.ChildWatches = old.RelativeZxid, old.DataWatches, old.ExistWatches, old.ChildWatches
+		} else if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		if req.DataWatches == nil || req.ExistWatches == nil || req.ChildWatches == nil ||
+			(opcode == opSetWatches2 && (req.PersistentWatches == nil || req.PersistentRecursiveWatches == nil)) {
+			// 与真实服务端一样，不接受长度为-1的列表
+			return errMarshallingError, nil
+		}
+		s.setWatches(sess, req)
+		return errOk, nil
+	case opAddWatch:
+		req := &proto.AddWatchRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		if req.Mode == 1 {
+			addWatch(s.recursive, req.Path, sess.id)
+		} else {
+			addWatch(s.persistent, req.Path, sess.id)
+		}
+		return errOk, nil
+	case opCheckWatches, opRemoveWatches:
+		req := &proto.CheckWatchesRequest{}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		found := false
+		for _, watches := range s.watchesOfType(req.Type) {
+			if watches[req.Path][sess.id] {
+				found = true
+				if opcode == opRemoveWatches {
+					delete(watches[req.Path], sess.id)
+					if len(watches[req.Path]) == 0 {
+						delete(watches, req.Path)
+					}
+				}
+			}
+		}
+		if !found {
+			return errNoWatcher, nil
+		}
+		return errOk, nil
+	}
+	return errUnimplemented, nil
+}
+
+func isWrite(opcode int32) bool {
+	switch opcode {
+	case opCreate, opCreate2, opCreateContainer, opCreateTTL, opDelete, opSet, opSetAcl, opMulti:
+		return true
+	}
+	return false
+}
+
+func decodeCreate(opcode int32, d *jute.Decoder) (*proto.CreateRequest, error) {
+	if opcode == opCreateTTL {
+		req := &proto.CreateTTLRequest{}
+		if err := d.Decode(req); err != nil {
+			return nil, err
+		}
+		return &proto.CreateRequest{Path: req.Path, Data: req.Data, Acl: req.Acl, Flags: req.Flags}, nil
+	}
+	req := &proto.CreateRequest{}
+	if err := d.Decode(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// 新建节点的响应，只有opCreate不返回节点状态
+func createResult(opcode int32, path string, stat *proto.Stat) interface{} {
+	if opcode == opCreate {
+		return &proto.CreateResponse{Path: path}
+	}
+	return &proto.Create2Response{Path: path, Stat: *stat}
+}
+
+// 在节点树的副本上执行写操作，成功后替换节点树并触发监视，调用时需持有s.mu
+func (s *Server) write(sess *session, fn func(t *tree, tx *txn) int32) int32 {
+	t := s.tree.clone()
+	tx := &txn{zxid: s.zxid + 1, time: time.Now().UnixNano() / 1e6, session: sess.id}
+	code := fn(t, tx)
+	if code != errOk {
+		return code
+	}
+	s.zxid++
+	s.tree = t
+	s.trigger(tx.changes)
+	return errOk
+}
+
+type multiOp struct {
+	opcode int32
+	req    interface{}
+}
+
+type multiResponse struct {
+	results []multiResult
+}
+
+type multiResult struct {
+	opcode int32
+	code   int32
+	res    interface{}
+}
+
+func (r *multiResponse) Serialize(e *jute.Encoder) error {
+	for _, res := range r.results {
+		if res.code != errOk || res.opcode == opError {
+			e.Encode(&proto.MultiHeader{Type: opError, Done: false, Err: res.code})
+			e.Encode(&proto.ErrorResponse{Err: res.code})
+			continue
+		}
+		e.Encode(&proto.MultiHeader{Type: res.opcode, Done: false, Err: 0})
+		if res.res != nil {
+			if err := e.Encode(res.res); err != nil {
+				return err
+			}
+		}
+	}
+	return e.Encode(&proto.MultiHeader{Type: -1, Done: true, Err: -1})
+}
+
+// 多操作事务，任何一个操作失败时所有操作都不生效
+func (s *Server) multi(sess *session, d *jute.Decoder) (int32, interface{}) {
+	var ops []multiOp
+	for {
+		h := &proto.MultiHeader{}
+		if err := d.Decode(h); err != nil {
+			return errMarshallingError, nil
+		}
+		if h.Done {
+			break
+		}
+		var req jute.Deserializer
+		switch h.Type {
+		case opCreate, opCreate2, opCreateContainer, opCreateTTL:
+			req, err := decodeCreate(h.Type, d)
+			if err != nil {
+				return errMarshallingError, nil
+			}
+			ops = append(ops, multiOp{h.Type, req})
+			continue
+		case opDelete:
+			req = &proto.DeleteRequest{}
+		case opSet:
+			req = &proto.SetDataRequest{}
+		case opCheck:
+			req = &proto.CheckVersionRequest{}
+		default:
+			return errUnimplemented, nil
+		}
+		if err := d.Decode(req); err != nil {
+			return errMarshallingError, nil
+		}
+		ops = append(ops, multiOp{h.Type, req})
+	}
+
+	res := &multiResponse{results: make([]multiResult, len(ops))}
+	failed := int32(errOk)
+	code := s.write(sess, func(t *tree, tx *txn) int32 {
+		for i, op := range ops {
+			r := multiResult{opcode: op.opcode, code: errOk}
+			switch req := op.req.(type) {
+			case *proto.CreateRequest:
+				path, stat, code := t.create(tx, req.Path, req.Data, req.Acl, req.Flags)
+				r.code = code
+				if code == errOk {
+					r.res = createResult(op.opcode, path, stat)
+				}
+			case *proto.DeleteRequest:
+				r.code = t.delete(tx, req.Path, req.Version)
+			case *proto.SetDataRequest:
+				stat, code := t.set(tx, req.Path, req.Data, req.Version)
+				r.code = code
+				if code == errOk {
+					r.res = &proto.SetDataResponse{Stat: *stat}
+				}
+			case *proto.CheckVersionRequest:
+				r.code = t.check(req.Path, req.Version)
+			}
+			res.results[i] = r
+			if r.code != errOk {
+				failed = r.code
+				// 失败操作之前的结果为成功，之后的为errRuntimeInconsistency
+				for j := range res.results {
+					switch {
+					case j < i:
+						res.results[j] = multiResult{opError, errOk, nil}
+					case j > i:
+						res.results[j] = multiResult{opError, errRuntimeInconsistency, nil}
+					}
+				}
+				return r.code
+			}
+		}
+		return errOk
+	})
+	if code != errOk {
+		return failed, res
+	}
+	return errOk, res
+}
+
+func addWatch(watches map[string]map[int64]bool, path string, id int64) {
+	if watches[path] == nil {
+		watches[path] = make(map[int64]bool)
+	}
+	watches[path][id] = true
+}
+
+// 取出并删除监视，监视只触发一次
+func takeWatch(watches map[string]map[int64]bool, path string, ids map[int64]bool) {
+	for id := range watches[path] {
+		ids[id] = true
+	}
+	delete(watches, path)
+}
+
+// 重新登记监视，断开期间已经发生的变化立即发送事件，调用时需持有s.mu
+func (s *Server) setWatches(sess *session, req *proto.SetWatches2) {
+	notify := func(eventType int32, path string) {
+		send(sess.conn, &proto.ReplyHeader{Xid: -1, Zxid: -1, Err: errOk}, &proto.WatcherEvent{
+			Type:  eventType,
+			State: stateSyncConnected,
+			Path:  path,
+		})
+	}
+	for _, path := range req.DataWatches {
+		n, ok := s.tree.nodes[path]
+		switch {
+		case !ok:
+			notify(eventNodeDeleted, path)
+		case n.stat.Mzxid > req.RelativeZxid:
+			notify(eventNodeDataChanged, path)
+		default:
+			addWatch(s.dataWatches, path, sess.id)
+		}
+	}
+	for _, path := range req.ExistWatches {
+		if _, ok := s.tree.nodes[path]; ok {
+			notify(eventNodeCreated, path)
+		} else {
+			addWatch(s.dataWatches, path, sess.id)
+		}
+	}
+	for _, path := range req.ChildWatches {
+		n, ok := s.tree.nodes[path]
+		switch {
+		case !ok:
+			notify(eventNodeDeleted, path)
+		case n.stat.Pzxid > req.RelativeZxid:
+			notify(eventNodeChildrenChanged, path)
+		default:
+			addWatch(s.childWatches, path, sess.id)
+		}
+	}
+	for _, path := range req.PersistentWatches {
+		addWatch(s.persistent, path, sess.id)
+	}
+	for _, path := range req.PersistentRecursiveWatches {
+		addWatch(s.recursive, path, sess.id)
+	}
+}
+
+// 监视类型对应的登记表
+func (s *Server) watchesOfType(wType int32) []map[string]map[int64]bool {
+	switch wType {
+	case watcherChildren:
+		return []map[string]map[int64]bool{s.childWatches}
+	case watcherData:
+		return []map[string]map[int64]bool{s.dataWatches}
+	case watcherAny:
+		return []map[string]map[int64]bool{s.dataWatches, s.childWatches, s.persistent, s.recursive}
+	case watcherPersistent:
+		return []map[string]map[int64]bool{s.persistent}
+	case watcherPersistentRecursive:
+		return []map[string]map[int64]bool{s.recursive}
+	}
+	return nil
+}
+
+// 根据节点变化向登记了监视的会话发送事件，每个会话对每个变化只收到一个事件，调用时需持有s.mu
+func (s *Server) trigger(changes []change) {
+	for _, c := range changes {
+		ids := make(map[int64]bool)
+		switch c.eventType {
+		case eventNodeCreated, eventNodeDataChanged:
+			takeWatch(s.dataWatches, c.path, ids)
+		case eventNodeDeleted:
+			takeWatch(s.dataWatches, c.path, ids)
+			takeWatch(s.childWatches, c.path, ids)
+		case eventNodeChildrenChanged:
+			takeWatch(s.childWatches, c.path, ids)
+		}
+		for id := range s.persistent[c.path] {
+			ids[id] = true
+		}
+		if c.eventType != eventNodeChildrenChanged {
+			for path := c.path; ; path, _ = parentPath(path) {
+				for id := range s.recursive[path] {
+					ids[id] = true
+				}
+				if path == "/" {
+					break
+				}
+			}
+		}
+		for id := range ids {
+			sess, ok := s.sessions[id]
+			if !ok || sess.conn == nil {
+				continue
+			}
+			send(sess.conn, &proto.ReplyHeader{Xid: -1, Zxid: s.zxid, Err: errOk}, &proto.WatcherEvent{
+				Type:  c.eventType,
+				State: stateSyncConnected,
+				Path:  c.path,
+			})
+		}
+	}
+}
@@ -0,0 +1,464 @@
+// zktest提供一个在本地回环地址上运行的ZooKeeper服务端，用于不依赖真实集群的测试：
+//
+//	srv, err := zktest.NewServer()
+//	defer srv.Close()
+//	cli := zk.New()
+//	cli.Connect([]string{srv.Addr()})
+//
+// 支持连接认证、心跳、增删改查、子节点、监视（包括持久监视）、多操作事务及随会话结束删除的临时节点。
+// 可通过SetReadOnly模拟失去多数派后只读的服务端，通过Stop及Start模拟服务端重启，通过SetDelay模拟延迟。
+// 不检查ACL，也不回收容器节点及TTL节点。
+// 默认接受任何认证信息，通过SetDigestUser登记用户后，digest认证的密码不符时返回认证失败。
+package zktest
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xianmau/gozk/jute"
+	"github.com/xianmau/gozk/proto"
+)
+
+const (
+	opCreate          = 1
+	opDelete          = 2
+	opExists          = 3
+	opGet             = 4
+	opSet             = 5
+	opGetAcl          = 6
+	opSetAcl          = 7
+	opChildren        = 8
+	opSync            = 9
+	opPing            = 11
+	opChildren2       = 12
+	opCheck           = 13
+	opMulti           = 14
+	opCreate2         = 15
+	opCheckWatches    = 17
+	opRemoveWatches   = 18
+	opCreateContainer = 19
+	opCreateTTL       = 21
+	opAddWatch        = 106
+	opAuth            = 100
+	opSetWatches      = 101
+	opSetWatches2     = 105
+	opClose           = -11
+	opError           = -1
+)
+
+const (
+	flagPersistent              = 0
+	flagEphemeral               = 1
+	flagSequential              = 2
+	flagEphemeralSequential     = 3
+	flagContainer               = 4
+	flagPersistentTTL           = 5
+	flagPersistentSequentialTTL = 6
+)
+
+const (
+	errOk                      = 0
+	errRuntimeInconsistency    = -2
+	errMarshallingError        = -5
+	errUnimplemented           = -6
+	errBadArguments            = -8
+	errNoNode                  = -101
+	errBadVersion              = -103
+	errNoChildrenForEphemerals = -108
+	errNodeExists              = -110
+	errNotEmpty                = -111
+	errInvalidACL              = -114
+	errAuthFailed              = -115
+	errNotReadOnly             = -119
+	errNoWatcher               = -121
+)
+
+const (
+	eventNodeCreated         = 1
+	eventNodeDeleted         = 2
+	eventNodeDataChanged     = 3
+	eventNodeChildrenChanged = 4
+	stateSyncConnected       = 3
+)
+
+const (
+	watcherChildren            = 1
+	watcherData                = 2
+	watcherAny                 = 3
+	watcherPersistent          = 4
+	watcherPersistentRecursive = 5
+)
+
+const (
+	maxPacketSize  = 1024*1024 + 1024 // 最大包大小
+	writeTimeout   = time.Second      // 写超时，避免不读数据的客户端卡住服务端
+	checkInterval  = 50 * time.Millisecond
+	minSessionTime = 100 * time.Millisecond // 允许的最小会话超时，便于测试会话过期
+)
+
+var worldACL = []proto.ACL{{Perms: 31, Id: proto.Id{Scheme: "world", Id: "anyone"}}}
+
+type session struct {
+	id       int64
+	password []byte
+	timeout  time.Duration
+	lastSeen time.Time
+	conn     net.Conn // 当前连接，断开时为空
+	auths    []string // 当前连接上已认证的身份，与真实服务端一样随连接失效
+}
+
+// 运行在回环地址上的服务端，所有状态都在内存中
+type Server struct {
+	addr          string
+	mu            sync.Mutex   // 保护以下所有字段，响应及事件也在锁内发送以保证顺序
+	ln            net.Listener // 停止时为空
+	tree          *tree
+	zxid          int64
+	lastSessionId int64
+	sessions      map[int64]*session
+	conns         map[net.Conn]bool
+	dataWatches   map[string]map[int64]bool // 路径 -> 会话
+	childWatches  map[string]map[int64]bool
+	persistent    map[string]map[int64]bool // 持久监视，触发后不删除
+	recursive     map[string]map[int64]bool // 递归持久监视，子树上的变化也会触发
+	digestUsers   map[string]string         // 用户名 -> digest ID，为空时接受任何认证信息
+	readOnly      bool                      // 只读时只接受允许只读的连接，写操作返回errNotReadOnly
+	delay         time.Duration             // 处理每个请求前的等待
+	closed        bool
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// 在127.0.0.1的随机端口上启动服务端
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		addr:         ln.Addr().String(),
+		ln:           ln,
+		tree:         newTree(),
+		sessions:     make(map[int64]*session),
+		conns:        make(map[net.Conn]bool),
+		dataWatches:  make(map[string]map[int64]bool),
+		childWatches: make(map[string]map[int64]bool),
+		persistent:   make(map[string]map[int64]bool),
+		recursive:    make(map[string]map[int64]bool),
+		digestUsers:  make(map[string]string),
+		done:         make(chan struct{}),
+	}
+	s.wg.Add(2)
+	go s.acceptLoop(ln)
+	go s.expireLoop()
+	return s, nil
+}
+
+// 服务端地址，可直接传给ZkCli.Connect，重启后不变
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// 关闭服务端，断开所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// 停止监听并断开所有连接，保留节点及会话，停止期间会话不会过期
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ln == nil {
+		return
+	}
+	s.ln.Close()
+	s.ln = nil
+	for c := range s.conns {
+		c.Close()
+	}
+	for _, sess := range s.sessions {
+		s.unbind(sess)
+	}
+}
+
+// 在原来的地址上重新监听，会话的超时重新计算
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ln != nil {
+		return nil
+	}
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+	now := time.Now()
+	for _, sess := range s.sessions {
+		sess.lastSeen = now
+	}
+	s.wg.Add(1)
+	go s.acceptLoop(ln)
+	return nil
+}
+
+// 切换只读模式并断开所有连接，与服务端失去或恢复多数派后重启的行为一致
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = readOnly
+	for c := range s.conns {
+		c.Close()
+	}
+	for _, sess := range s.sessions {
+		s.unbind(sess)
+	}
+}
+
+// 处理每个请求前等待d，模拟网络或服务端的延迟，用于测试超时及取消，d为0时不等待
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// 立即让会话过期，删除其临时节点，并断开它的连接
+func (s *Server) ExpireSession(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		s.closeSession(sess)
+	}
+}
+
+// 断开会话当前的连接但保留会话，用于测试重连，与真实服务端一样监视随连接失效
+func (s *Server) Disconnect(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[id]; ok {
+		s.unbind(sess)
+	}
+}
+
+// 登记或修改digest用户，之后digest认证只接受登记过的用户名及密码
+func (s *Server) SetDigestUser(user, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.digestUsers[user] = digestId(user, password)
+}
+
+// 会话当前连接上已认证的身份，格式为scheme:id，如"digest:user:base64(sha1(user:password))"
+func (s *Server) Auths(id int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), sess.auths...)
+}
+
+// 当前所有会话的ID
+func (s *Server) Sessions() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]int64, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *Server) acceptLoop(ln net.Listener) {
+	defer s.wg.Done()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed || s.ln != ln {
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// 定期检查超时未收到数据的会话，停止期间不检查
+func (s *Server) expireLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			now := time.Now()
+			for _, sess := range s.sessions {
+				if s.ln != nil && now.Sub(sess.lastSeen) > sess.timeout {
+					s.closeSession(sess)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// 结束会话，调用时需持有s.mu
+func (s *Server) closeSession(sess *session) {
+	delete(s.sessions, sess.id)
+	for _, path := range s.tree.ephemerals(sess.id) {
+		s.zxid++
+		tx := &txn{zxid: s.zxid, time: time.Now().UnixNano() / 1e6, session: sess.id}
+		s.tree.delete(tx, path, -1)
+		s.trigger(tx.changes)
+	}
+	s.unbind(sess)
+}
+
+// 断开会话的连接并删除它登记的所有监视，客户端重连后通过SetWatches重新登记，调用时需持有s.mu
+func (s *Server) unbind(sess *session) {
+	for _, watches := range s.watchesOfType(watcherAny) {
+		for path, ids := range watches {
+			delete(ids, sess.id)
+			if len(ids) == 0 {
+				delete(watches, path)
+			}
+		}
+	}
+	if sess.conn != nil {
+		sess.conn.Close()
+		sess.conn = nil
+	}
+	sess.auths = nil
+}
+
+// digest方案的ID，格式为 user:base64(sha1(user:password))
+func digestId(user, password string) string {
+	h := sha1.Sum([]byte(user + ":" + password))
+	return user + ":" + base64.StdEncoding.EncodeToString(h[:])
+}
+
+// 校验认证信息并记录到会话当前的连接上，调用时需持有s.mu
+func (s *Server) auth(sess *session, req *proto.AuthPacket) int32 {
+	id := string(req.Auth)
+	if req.Scheme == "digest" {
+		i := strings.IndexByte(id, ':')
+		if i < 0 {
+			return errAuthFailed
+		}
+		user := id[:i]
+		id = digestId(user, id[i+1:])
+		if len(s.digestUsers) > 0 && s.digestUsers[user] != id {
+			return errAuthFailed
+		}
+	}
+	sess.auths = append(sess.auths, req.Scheme+":"+id)
+	return errOk
+}
+
+// 建立新会话或恢复原有会话，会话不存在或密码不符时返回nil
+func (s *Server) attach(req *proto.ConnectRequest, c net.Conn) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.SessionId != 0 {
+		sess, ok := s.sessions[req.SessionId]
+		if !ok || string(sess.password) != string(req.Passwd) {
+			return nil
+		}
+		s.unbind(sess)
+		sess.conn = c
+		sess.lastSeen = time.Now()
+		return sess
+	}
+	timeout := time.Duration(req.TimeOut) * time.Millisecond
+	if timeout < minSessionTime {
+		timeout = minSessionTime
+	}
+	s.lastSessionId++
+	sess := &session{
+		id:       s.lastSessionId,
+		password: make([]byte, 16),
+		timeout:  timeout,
+		lastSeen: time.Now(),
+		conn:     c,
+	}
+	rand.Read(sess.password)
+	s.sessions[sess.id] = sess
+	return sess
+}
+
+// 连接断开，会话保留到超时
+func (s *Server) detach(sess *session, c net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, c)
+	if sess != nil && sess.conn == c {
+		s.unbind(sess)
+	}
+}
+
+// 发送一个包：长度 + 各记录，调用时需持有s.mu
+func send(c net.Conn, records ...interface{}) error {
+	e := jute.NewEncoder()
+	e.WriteInt(0)
+	for _, r := range records {
+		if err := e.Encode(r); err != nil {
+			return err
+		}
+	}
+	buf := e.Bytes()
+	n := len(buf) - 4
+	buf[0], buf[1], buf[2], buf[3] = byte(n>>24), byte(n>>16), byte(n>>8), byte(n)
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.Write(buf)
+	return err
+}
+
+func readPacket(c net.Conn) ([]byte, error) {
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(c, sizeBuf[:]); err != nil {
+		return nil, err
+	}
+	return readPacketBody(c, sizeBuf)
+}
+
+// 读取长度之后的包内容
+func readPacketBody(c net.Conn, sizeBuf [4]byte) ([]byte, error) {
+	size := int(sizeBuf[0])<<24 | int(sizeBuf[1])<<16 | int(sizeBuf[2])<<8 | int(sizeBuf[3])
+	if size < 0 || size > maxPacketSize {
+		return nil, jute.ErrBadLength
+	}
+	buf := make([]byte, size)
+	_, err := io.ReadFull(c, buf)
+	return buf, err
+}
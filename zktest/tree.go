@@ -0,0 +1,209 @@
+package zktest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xianmau/gozk/proto"
+)
+
+type node struct {
+	data     []byte
+	acl      []proto.ACL
+	stat     proto.Stat
+	children map[string]bool
+}
+
+// 节点树，多操作事务在副本上执行，全部成功后再替换
+type tree struct {
+	nodes map[string]*node
+}
+
+// 节点变化，用于触发监视
+type change struct {
+	eventType int32
+	path      string
+}
+
+// 一次写操作的上下文
+type txn struct {
+	zxid    int64
+	time    int64 // 单位：毫秒
+	session int64
+	changes []change
+}
+
+func newTree() *tree {
+	return &tree{
+		nodes: map[string]*node{
+			"/": {acl: worldACL, children: map[string]bool{}},
+		},
+	}
+}
+
+func (t *tree) clone() *tree {
+	nodes := make(map[string]*node, len(t.nodes))
+	for path, n := range t.nodes {
+		c := *n
+		c.children = make(map[string]bool, len(n.children))
+		for child := range n.children {
+			c.children[child] = true
+		}
+		nodes[path] = &c
+	}
+	return &tree{nodes}
+}
+
+func parentPath(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/", path[1:]
+	}
+	return path[:i], path[i+1:]
+}
+
+// 路径必须以/开头，不能以/结尾（根节点除外），不能有空的节点名
+func validPath(path string) bool {
+	if path == "/" {
+		return true
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/") {
+		return false
+	}
+	for _, name := range strings.Split(path[1:], "/") {
+		if name == "" || name == "." || name == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tree) create(tx *txn, path string, data []byte, acl []proto.ACL, flags int32) (string, *proto.Stat, int32) {
+	if !validPath(path) || path == "/" {
+		return "", nil, errBadArguments
+	}
+	if len(acl) == 0 {
+		return "", nil, errInvalidACL
+	}
+	parent, _ := parentPath(path)
+	p, ok := t.nodes[parent]
+	if !ok {
+		return "", nil, errNoNode
+	}
+	if p.stat.EphemeralOwner != 0 {
+		return "", nil, errNoChildrenForEphemerals
+	}
+	if flags == flagSequential || flags == flagEphemeralSequential || flags == flagPersistentSequentialTTL {
+		path += fmt.Sprintf("%010d", p.stat.Cversion)
+	}
+	if _, ok := t.nodes[path]; ok {
+		return "", nil, errNodeExists
+	}
+	n := &node{
+		data:     data,
+		acl:      acl,
+		children: map[string]bool{},
+		stat: proto.Stat{
+			Czxid:      tx.zxid,
+			Mzxid:      tx.zxid,
+			Ctime:      tx.time,
+			Mtime:      tx.time,
+			DataLength: int32(len(data)),
+			Pzxid:      tx.zxid,
+		},
+	}
+	if flags == flagEphemeral || flags == flagEphemeralSequential {
+		n.stat.EphemeralOwner = tx.session
+	}
+	t.nodes[path] = n
+	_, name := parentPath(path)
+	p.children[name] = true
+	p.stat.Cversion++
+	p.stat.NumChildren++
+	p.stat.Pzxid = tx.zxid
+	tx.changes = append(tx.changes, change{eventNodeCreated, path}, change{eventNodeChildrenChanged, parent})
+	stat := n.stat
+	return path, &stat, errOk
+}
+
+func (t *tree) delete(tx *txn, path string, version int32) int32 {
+	if !validPath(path) || path == "/" {
+		return errBadArguments
+	}
+	n, ok := t.nodes[path]
+	if !ok {
+		return errNoNode
+	}
+	if version != -1 && version != n.stat.Version {
+		return errBadVersion
+	}
+	if len(n.children) > 0 {
+		return errNotEmpty
+	}
+	delete(t.nodes, path)
+	parent, name := parentPath(path)
+	p := t.nodes[parent]
+	delete(p.children, name)
+	p.stat.Cversion++
+	p.stat.NumChildren--
+	p.stat.Pzxid = tx.zxid
+	tx.changes = append(tx.changes, change{eventNodeDeleted, path}, change{eventNodeChildrenChanged, parent})
+	return errOk
+}
+
+func (t *tree) set(tx *txn, path string, data []byte, version int32) (*proto.Stat, int32) {
+	n, ok := t.nodes[path]
+	if !ok {
+		return nil, errNoNode
+	}
+	if version != -1 && version != n.stat.Version {
+		return nil, errBadVersion
+	}
+	n.data = data
+	n.stat.Version++
+	n.stat.Mzxid = tx.zxid
+	n.stat.Mtime = tx.time
+	n.stat.DataLength = int32(len(data))
+	tx.changes = append(tx.changes, change{eventNodeDataChanged, path})
+	stat := n.stat
+	return &stat, errOk
+}
+
+func (t *tree) setACL(tx *txn, path string, acl []proto.ACL, version int32) (*proto.Stat, int32) {
+	n, ok := t.nodes[path]
+	if !ok {
+		return nil, errNoNode
+	}
+	if len(acl) == 0 {
+		return nil, errInvalidACL
+	}
+	if version != -1 && version != n.stat.Aversion {
+		return nil, errBadVersion
+	}
+	n.acl = acl
+	n.stat.Aversion++
+	stat := n.stat
+	return &stat, errOk
+}
+
+func (t *tree) check(path string, version int32) int32 {
+	n, ok := t.nodes[path]
+	if !ok {
+		return errNoNode
+	}
+	if version != -1 && version != n.stat.Version {
+		return errBadVersion
+	}
+	return errOk
+}
+
+// 会话拥有的临时节点
+func (t *tree) ephemerals(session int64) []string {
+	var paths []string
+	for path, n := range t.nodes {
+		if n.stat.EphemeralOwner == session {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}